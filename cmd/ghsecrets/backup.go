@@ -0,0 +1,293 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/gcp"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+// backupStore is implemented by the AWS and GCP JSON clients
+type backupStore interface {
+	AddOrUpdateKey(ctx context.Context, key, value string) error
+	AddOrUpdateKeys(ctx context.Context, keys map[string]string) error
+	GetKey(ctx context.Context, key string) (string, error)
+	GetAllKeys(ctx context.Context) (map[string]string, error)
+	RemoveKey(ctx context.Context, key string) error
+	LastUpdated(ctx context.Context) (time.Time, error)
+}
+
+// newAWSClient creates the AWS Secrets Manager client of the backup. Tests replace it with a mock.
+var newAWSClient = func(opts aws.ClientOptions) (aws.SecretClient, error) {
+	return aws.NewClientWithOptions(opts)
+}
+
+// gcpBackupClient is the GCP Secret Manager client of a backup
+type gcpBackupClient interface {
+	gcp.SecretClient
+	Close() error
+}
+
+// newGCPClient creates the GCP Secret Manager client of the backup. Tests replace it with a mock.
+var newGCPClient = func(projectID, credentialsPath string) (gcpBackupClient, error) {
+	return gcp.NewClient(projectID, credentialsPath)
+}
+
+// backupNames maps backend identifiers to their display names
+var backupNames = map[string]string{
+	"aws": "AWS Secrets Manager",
+	"gcp": "GCP Secret Manager",
+}
+
+// backupSecretName returns the configured secret name for the backend,
+// falling back to the default name derived from the GitHub organization or repository
+func backupSecretName(backend string) (string, error) {
+	if name := viper.GetString(backend + ".secret_name"); name != "" {
+		return name, nil
+	}
+
+	if githubOrg := viper.GetString("github.org"); githubOrg != "" {
+		return fmt.Sprintf("github-secrets-%s", githubOrg), nil
+	}
+
+	githubOwner := viper.GetString("github.owner")
+	githubRepo := viper.GetString("github.repo")
+	if githubOwner == "" || githubRepo == "" {
+		return "", fmt.Errorf("%s secret name must be configured in ghsecrets.yaml", strings.ToUpper(backend))
+	}
+
+	return repoBackupSecretName(githubOwner, githubRepo), nil
+}
+
+// repoBackupSecretName returns the default backup secret name of a repository
+func repoBackupSecretName(owner, repo string) string {
+	return fmt.Sprintf("github-secrets-%s-%s", owner, repo)
+}
+
+// sourceBackupSecretName returns the backup secret to read from: the secret named with
+// --from-secret-name, the default secret of the --source-repo repository, or the
+// configured secret of the destination
+func sourceBackupSecretName(backend, fromSecretName, sourceRepo string) (string, error) {
+	if fromSecretName != "" {
+		return fromSecretName, nil
+	}
+	if sourceRepo != "" {
+		owner, repo, err := parseRepository(sourceRepo)
+		if err != nil {
+			return "", err
+		}
+		return repoBackupSecretName(owner, repo), nil
+	}
+	return backupSecretName(backend)
+}
+
+// parseRepository splits an owner/repo argument
+func parseRepository(value string) (string, string, error) {
+	owner, repo, ok := strings.Cut(value, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("invalid repository %q (must be owner/repo)", value)
+	}
+	return owner, repo, nil
+}
+
+// backupSection returns the bundle section matching the configured GitHub target
+func backupSection() string {
+	if githubOrg := viper.GetString("github.org"); githubOrg != "" {
+		return bundle.OrgSection(githubOrg)
+	}
+	return repoBackupSection()
+}
+
+// repoBackupSection returns the bundle section of the configured repository or
+// environment, for commands that ignore a configured organization
+func repoBackupSection() string {
+	if environment := viper.GetString("github.environment"); environment != "" {
+		return bundle.EnvironmentSection(environment)
+	}
+	return bundle.RootSection
+}
+
+// storeBackupSection returns the bundle section for the given section of the Actions
+// store in another secret store. Actions secrets keep their original sections.
+func storeBackupSection(store github.Store, section string) string {
+	if store == github.StoreActions {
+		return section
+	}
+	return bundle.StoreSection(string(store), section)
+}
+
+// openBackupStore creates the JSON client for the given backend from config,
+// scoped to the bundle section of the configured GitHub target.
+// The returned function releases the underlying client and must be called when done.
+func openBackupStore(backend string) (backupStore, string, func() error, error) {
+	sections, secretName, closeStore, err := openBackupSections(backend)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return sections(backupSection()), secretName, closeStore, nil
+}
+
+// openBackupSections is like openBackupStore but lets the caller pick the bundle sections
+func openBackupSections(backend string) (func(section string) backupStore, string, func() error, error) {
+	secret, err := openBackupSecret(backend, "")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return secret.sections, secret.name, secret.close, nil
+}
+
+// backupSecret is the opened backup secret of a backend
+type backupSecret struct {
+	name     string
+	sections func(section string) backupStore
+	// sectionAt reads a section of an earlier version of the secret
+	sectionAt func(versionID, section string) backupStore
+	versions  func(ctx context.Context) ([]bundle.Version, error)
+	close     func() error
+}
+
+// openBackupSecret creates the JSON client for the given backend from config.
+// A non-empty versionID pins reads to that earlier version of the secret, which is read-only.
+// When encryption is configured, values are sealed before they reach the backend.
+func openBackupSecret(backend, versionID string) (*backupSecret, error) {
+	secretName, err := backupSecretName(backend)
+	if err != nil {
+		return nil, err
+	}
+	return openNamedBackupSecret(backend, secretName, versionID)
+}
+
+// openNamedBackupSecret is like openBackupSecret for a backup secret other than the configured one
+func openNamedBackupSecret(backend, secretName, versionID string) (*backupSecret, error) {
+	return openSealedBackupSecret(backend, secretName, versionID, false)
+}
+
+// openDestinationBackupSecret opens the backup secret of the destination of a copy.
+// Unlike the backup of a push, a missing AWS secret is read as empty and created on
+// first write, since the destination is usually a new repository.
+func openDestinationBackupSecret(backend, secretName string) (*backupSecret, error) {
+	return openSealedBackupSecret(backend, secretName, "", true)
+}
+
+// openSealedBackupSecret opens the named backup secret, sealing values when encryption is configured
+func openSealedBackupSecret(backend, secretName, versionID string, createMissing bool) (*backupSecret, error) {
+	secret, err := openBackendSecret(backend, secretName, versionID, createMissing)
+	if err != nil {
+		return nil, err
+	}
+
+	sealer, err := newSealer()
+	if err != nil {
+		secret.close()
+		return nil, err
+	}
+	if sealer != nil {
+		sections, sectionAt := secret.sections, secret.sectionAt
+		secret.sections = func(section string) backupStore {
+			return &sealedStore{backupStore: sections(section), sealer: sealer}
+		}
+		secret.sectionAt = func(versionID, section string) backupStore {
+			return &sealedStore{backupStore: sectionAt(versionID, section), sealer: sealer}
+		}
+	}
+	return secret, nil
+}
+
+// openBackendSecret creates the unencrypted JSON client of the named secret for the given backend.
+// createMissing only matters for AWS; GCP secrets are always created on first write.
+func openBackendSecret(backend, secretName, versionID string, createMissing bool) (*backupSecret, error) {
+	switch backend {
+	case "aws":
+		awsRegion := viper.GetString("aws.region")
+		if awsRegion == "" {
+			awsRegion = "us-east-1"
+		}
+
+		tags, err := parseAWSTags(viper.GetStringSlice("aws.tags"))
+		if err != nil {
+			return nil, err
+		}
+
+		awsClient, err := newAWSClient(aws.ClientOptions{
+			Region:   awsRegion,
+			Profile:  viper.GetString("aws.profile"),
+			KMSKeyID: viper.GetString("aws.kms_key_id"),
+			Tags:     tags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS client: %w", err)
+		}
+
+		jsonClient := aws.NewJSONClient(awsClient, secretName)
+		if createMissing {
+			jsonClient = jsonClient.CreateIfMissing()
+		}
+		sectionAt := func(versionID, section string) backupStore {
+			if versionID == "" {
+				return jsonClient.Section(section)
+			}
+			return jsonClient.AtVersion(versionID).Section(section)
+		}
+		return &backupSecret{
+			name:      secretName,
+			sections:  func(section string) backupStore { return sectionAt(versionID, section) },
+			sectionAt: sectionAt,
+			versions:  jsonClient.Versions,
+			close:     func() error { return nil },
+		}, nil
+
+	case "gcp":
+		gcpProject := viper.GetString("gcp.project")
+		if gcpProject == "" {
+			return nil, fmt.Errorf("GCP project ID not specified. Use --gcp-project flag or configure in ghsecrets.yaml")
+		}
+
+		gcpClient, err := newGCPClient(gcpProject, viper.GetString("gcp.credentials_path"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP client: %w", err)
+		}
+
+		jsonClient := gcp.NewJSONClient(gcpClient, secretName)
+		sectionAt := func(versionID, section string) backupStore {
+			if versionID == "" {
+				return jsonClient.Section(section)
+			}
+			return jsonClient.AtVersion(versionID).Section(section)
+		}
+		return &backupSecret{
+			name:      secretName,
+			sections:  func(section string) backupStore { return sectionAt(versionID, section) },
+			sectionAt: sectionAt,
+			versions:  jsonClient.Versions,
+			close:     gcpClient.Close,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid backup source: %s (must be aws or gcp)", backend)
+	}
+}
+
+// parseAWSTags parses aws.tags entries of the form Key=Value. Tags are configured as a
+// list rather than a map because config map keys are lower-cased, while tag keys are not.
+func parseAWSTags(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	tags := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid aws.tags entry %q (must be Key=Value)", entry)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
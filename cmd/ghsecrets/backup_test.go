@@ -0,0 +1,77 @@
+package ghsecrets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/gcp"
+)
+
+// useMockAWS makes the AWS backups of the test use an in-memory mock
+func useMockAWS(t *testing.T) *aws.MockClient {
+	mock := aws.NewMockClient()
+	previous := newAWSClient
+	newAWSClient = func(aws.ClientOptions) (aws.SecretClient, error) { return mock, nil }
+	t.Cleanup(func() { newAWSClient = previous })
+	return mock
+}
+
+// useMockGCP makes the GCP backups of the test use an in-memory mock
+func useMockGCP(t *testing.T) *gcp.MockClient {
+	mock := gcp.NewMockClient("test-project")
+	previous := newGCPClient
+	newGCPClient = func(string, string) (gcpBackupClient, error) { return mock, nil }
+	t.Cleanup(func() { newGCPClient = previous })
+	return mock
+}
+
+func TestParseAWSTags(t *testing.T) {
+	tags, err := parseAWSTags([]string{"Owner=platform-team", "CostCenter = 1234", "Empty="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Owner": "platform-team", "CostCenter": "1234", "Empty": ""}, tags)
+
+	tags, err = parseAWSTags(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = parseAWSTags([]string{"Owner"})
+	assert.EqualError(t, err, `invalid aws.tags entry "Owner" (must be Key=Value)`)
+}
+
+func TestParseRepository(t *testing.T) {
+	owner, repo, err := parseRepository("my-org/service-template")
+	require.NoError(t, err)
+	assert.Equal(t, "my-org", owner)
+	assert.Equal(t, "service-template", repo)
+
+	for _, value := range []string{"my-org", "/repo", "my-org/", "a/b/c"} {
+		_, _, err := parseRepository(value)
+		assert.EqualError(t, err, fmt.Sprintf("invalid repository %q (must be owner/repo)", value))
+	}
+}
+
+func TestSourceBackupSecretName(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("github.owner", "my-org")
+	viper.Set("github.repo", "new-service")
+
+	name, err := sourceBackupSecretName("aws", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "github-secrets-my-org-new-service", name)
+
+	name, err = sourceBackupSecretName("aws", "", "my-org/service-template")
+	require.NoError(t, err)
+	assert.Equal(t, "github-secrets-my-org-service-template", name)
+
+	name, err = sourceBackupSecretName("aws", "shared-secrets", "")
+	require.NoError(t, err)
+	assert.Equal(t, "shared-secrets", name)
+
+	_, err = sourceBackupSecretName("aws", "", "service-template")
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+var (
+	copyBackup         string
+	copyFrom           string
+	copyTo             string
+	copyFromSecretName string
+	copyToSecretName   string
+	copyStore          string
+	copyDryRun         bool
+	copyConcurrency    int
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy backed-up secrets into another repository",
+	Long: `Copy the secrets and variables backed up for one repository into another, for
+example to set up a new service repository forked from a template.
+
+The backup of the source repository (github-secrets-<owner>-<repo>, or the secret
+named with --from-secret-name) is read, its keys are written to the backup of the
+destination repository (github-secrets-<owner>-<repo>, or --to-secret-name), which
+is created if it doesn't exist yet, and then pushed to the destination in GitHub.
+Secrets and variables that fail to push are rolled back in the destination backup,
+like 'ghsecrets push'.
+
+Use --environment to copy the secrets of an Actions environment into the
+environment of the same name, and --store to copy the Dependabot or Codespaces
+secrets instead of the Actions secrets. Both repositories are given explicitly,
+so a github.org setting in the config file is ignored.
+
+Example:
+  ghsecrets copy -b aws --from my-org/service-template --to my-org/new-service
+  ghsecrets copy -b gcp --from my-org/template --to my-org/api --environment production
+  ghsecrets copy -b aws --from-secret-name shared-secrets --to my-org/new-service --dry-run`,
+	RunE: runCopy,
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+
+	copyCmd.Flags().StringVarP(&copyBackup, "backup", "b", "", "Backup to copy through (aws, gcp)")
+	copyCmd.Flags().StringVar(&copyFrom, "from", "", "Source repository (owner/repo)")
+	copyCmd.Flags().StringVar(&copyTo, "to", "", "Destination repository (owner/repo)")
+	copyCmd.Flags().StringVar(&copyFromSecretName, "from-secret-name", "", "Backup secret to copy from instead of the one of --from")
+	copyCmd.Flags().StringVar(&copyToSecretName, "to-secret-name", "", "Backup secret to write instead of the one of --to")
+	copyCmd.MarkFlagsMutuallyExclusive("from", "from-secret-name")
+	copyCmd.MarkFlagRequired("to")
+
+	copyCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to copy the secrets of")
+	copyCmd.Flags().StringVar(&copyStore, "store", "actions", "Secret store to copy: actions, dependabot or codespaces")
+	copyCmd.Flags().BoolVar(&copyDryRun, "dry-run", false, "Print the planned changes without writing anything")
+	copyCmd.Flags().IntVar(&copyConcurrency, "concurrency", 4, "Number of keys to push in parallel")
+
+	copyCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	copyCmd.Flags().String("aws-profile", "", "AWS profile name")
+	copyCmd.Flags().String("gcp-project", "", "GCP project ID")
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	if copyBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+	if _, ok := backupNames[copyBackup]; !ok {
+		return fmt.Errorf("invalid backup source: %s (must be aws or gcp)", copyBackup)
+	}
+	if copyFrom == "" && copyFromSecretName == "" {
+		return fmt.Errorf("source must be specified with --from (owner/repo) or --from-secret-name")
+	}
+	if copyConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	store, err := github.ParseStore(copyStore)
+	if err != nil {
+		return err
+	}
+
+	toOwner, toRepo, err := parseRepository(copyTo)
+	if err != nil {
+		return err
+	}
+	sourceName, err := sourceBackupSecretName(copyBackup, copyFromSecretName, copyFrom)
+	if err != nil {
+		return err
+	}
+	destinationName := copyToSecretName
+	if destinationName == "" {
+		destinationName = repoBackupSecretName(toOwner, toRepo)
+	}
+	if sourceName == destinationName {
+		return fmt.Errorf("source and destination are the same backup secret '%s'", sourceName)
+	}
+
+	ctx := context.Background()
+
+	keys, variables, err := readCopySource(ctx, sourceName, store)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 && len(variables) == 0 {
+		fmt.Printf("No secrets found in %s secret '%s'\n", backupNames[copyBackup], sourceName)
+		return nil
+	}
+
+	githubClient, _, _, err := newGitHubClientFor("", toOwner, toRepo, viper.GetString("github.environment"))
+	if err != nil {
+		return err
+	}
+	githubClient = githubClient.WithStore(store)
+	secretsTarget := storeTargetName(store, toOwner, toRepo)
+
+	if copyDryRun {
+		return planRestore(ctx, githubClient, secretsTarget, githubTargetName(toOwner, toRepo), keys, variables)
+	}
+
+	fmt.Printf("Copying from %s secret '%s' to '%s' and %s\n", backupNames[copyBackup], sourceName, destinationName, secretsTarget)
+	return copyToDestination(ctx, destinationName, store, keys, variables, githubClient.CreateOrUpdateSecret, githubClient.CreateOrUpdateVariable)
+}
+
+// readCopySource reads the secrets of the store and the Actions variables from the source backup
+func readCopySource(ctx context.Context, sourceName string, store github.Store) (map[string]string, map[string]string, error) {
+	source, err := openNamedBackupSecret(copyBackup, sourceName, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer source.close()
+
+	keys, err := source.sections(storeBackupSection(store, repoBackupSection())).GetAllKeys(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[copyBackup], err)
+	}
+
+	var variables map[string]string
+	if store == github.StoreActions {
+		variables, err = source.sections(bundle.VariablesSection(repoBackupSection())).GetAllKeys(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve variables from %s: %w", backupNames[copyBackup], err)
+		}
+	}
+	return keys, variables, nil
+}
+
+// copyToDestination writes the secrets and variables to the destination backup and to GitHub
+func copyToDestination(ctx context.Context, destinationName string, store github.Store, keys, variables map[string]string, writeSecret, writeVariable secretWriter) error {
+	var copyErr error
+	if len(keys) > 0 {
+		copyErr = copyKeys(ctx, destinationName, storeBackupSection(store, repoBackupSection()), "secret", writeSecret, keys)
+	}
+
+	if len(variables) > 0 {
+		err := copyKeys(ctx, destinationName, bundle.VariablesSection(repoBackupSection()), "variable", writeVariable, variables)
+		if err != nil && copyErr == nil {
+			copyErr = err
+		}
+	}
+
+	return copyErr
+}
+
+// copyKeys writes the keys to a section of the destination backup and then to GitHub,
+// rolling back the backup values of the keys GitHub rejected
+func copyKeys(ctx context.Context, destinationName, section, kind string, write secretWriter, keys map[string]string) error {
+	fmt.Printf("Creating backup for %d %ss...\n", len(keys), kind)
+	open := func() (*backupSecret, error) { return openDestinationBackupSecret(copyBackup, destinationName) }
+	rollback, err := backupKeysTo(ctx, copyBackup, open, section, "", keys, nil)
+	if err != nil {
+		return fmt.Errorf("failed to backup to %s: %w", strings.ToUpper(copyBackup), err)
+	}
+	fmt.Printf("✓ Successfully backed up to %s\n", backupNames[copyBackup])
+
+	fmt.Printf("Pushing %d %ss\n", len(keys), kind)
+	return pushKeysWithRollback(ctx, copyBackup, kind, write, rollback, keys, copyConcurrency)
+}
@@ -0,0 +1,129 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+func TestCopyToDestination(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	viper.Reset()
+	// A configured organization doesn't apply to copies between repositories
+	viper.Set("github.org", "my-org")
+	copyBackup, copyConcurrency = "aws", 2
+	defer func() { copyBackup, copyConcurrency = "", 4 }()
+
+	mockAWS := useMockAWS(t)
+	sourceName := repoBackupSecretName("my-org", "service-template")
+	destinationName := repoBackupSecretName("my-org", "new-service")
+
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, sourceName, "{}", ""))
+	source := aws.NewJSONClient(mockAWS, sourceName)
+	require.NoError(t, source.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "sk-123", "TOKEN": "new", "DB_PASSWORD": "pw"}))
+	require.NoError(t, source.Section(bundle.VariablesSection(bundle.RootSection)).AddOrUpdateKeys(ctx, map[string]string{"REGION": "eu-west-1"}))
+	sourceBefore, err := mockAWS.GetSecret(ctx, sourceName)
+	require.NoError(t, err)
+
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, destinationName, `{"TOKEN":"old"}`, ""))
+
+	// GitHub rejects TOKEN, which existed in the destination backup, and the new DB_PASSWORD
+	mockGitHub := github.NewMockClient("test-token", "my-org", "new-service")
+	writeSecret := func(ctx context.Context, name, value string) error {
+		if name == "TOKEN" || name == "DB_PASSWORD" {
+			return fmt.Errorf("failed to create secret: %s", name)
+		}
+		return mockGitHub.CreateOrUpdateSecret(ctx, name, value)
+	}
+
+	keys, variables, err := readCopySource(ctx, sourceName, github.StoreActions)
+	require.NoError(t, err)
+	err = copyToDestination(ctx, destinationName, github.StoreActions, keys, variables, writeSecret, mockGitHub.CreateOrUpdateVariable)
+	assert.EqualError(t, err, "some secrets failed to push")
+
+	// The destination backup keeps only what was deployed
+	destination := aws.NewJSONClient(mockAWS, destinationName)
+	destinationKeys, err := destination.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "sk-123", "TOKEN": "old"}, destinationKeys)
+
+	destinationVariables, err := destination.Section(bundle.VariablesSection(bundle.RootSection)).GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"REGION": "eu-west-1"}, destinationVariables)
+
+	pushed, err := mockGitHub.GetSecret(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", pushed)
+	pushedVariables, err := mockGitHub.ListVariables(ctx)
+	require.NoError(t, err)
+	require.Len(t, pushedVariables, 1)
+	assert.Equal(t, "eu-west-1", pushedVariables[0].Value)
+
+	// The source backup is left untouched
+	sourceAfter, err := mockAWS.GetSecret(ctx, sourceName)
+	require.NoError(t, err)
+	assert.Equal(t, sourceBefore, sourceAfter)
+}
+
+func TestCopyToNewAWSDestination(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	viper.Reset()
+	copyBackup, copyConcurrency = "aws", 2
+	defer func() { copyBackup, copyConcurrency = "", 4 }()
+
+	// The backup secret of the destination repository doesn't exist yet
+	mockAWS := useMockAWS(t)
+	destinationName := repoBackupSecretName("my-org", "new-service")
+	mockGitHub := github.NewMockClient("test-token", "my-org", "new-service")
+
+	keys := map[string]string{"API_KEY": "sk-123"}
+	variables := map[string]string{"REGION": "eu-west-1"}
+	err := copyToDestination(ctx, destinationName, github.StoreActions, keys, variables, mockGitHub.CreateOrUpdateSecret, mockGitHub.CreateOrUpdateVariable)
+	require.NoError(t, err)
+
+	destination := aws.NewJSONClient(mockAWS, destinationName)
+	destinationKeys, err := destination.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, keys, destinationKeys)
+	destinationVariables, err := destination.Section(bundle.VariablesSection(bundle.RootSection)).GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, variables, destinationVariables)
+
+	pushed, err := mockGitHub.GetSecret(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", pushed)
+}
+
+func TestCopyVariablesRollback(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	viper.Reset()
+	copyBackup, copyConcurrency = "aws", 1
+	defer func() { copyBackup, copyConcurrency = "", 4 }()
+
+	mockAWS := useMockAWS(t)
+	destinationName := repoBackupSecretName("my-org", "new-service")
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, destinationName, "{}", ""))
+
+	mockGitHub := github.NewMockClient("test-token", "my-org", "new-service")
+	mockGitHub.SetError("CreateOrUpdateVariable", fmt.Errorf("failed to create variable"))
+
+	err := copyToDestination(ctx, destinationName, github.StoreActions, nil, map[string]string{"REGION": "eu-west-1"}, mockGitHub.CreateOrUpdateSecret, mockGitHub.CreateOrUpdateVariable)
+	assert.EqualError(t, err, "some variables failed to push")
+
+	variables, err := aws.NewJSONClient(mockAWS, destinationName).Section(bundle.VariablesSection(bundle.RootSection)).GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, variables)
+}
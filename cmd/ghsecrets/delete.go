@@ -0,0 +1,171 @@
+package ghsecrets
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+var (
+	deleteKey        string
+	deleteBackup     string
+	deleteGitHubOnly bool
+	deleteBackupOnly bool
+	deleteYes        bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a secret from GitHub and from the backup",
+	Long: `Delete a secret from GitHub Secrets and remove it from the backup bundle,
+so that a later restore does not bring it back.
+
+The key is removed from the backup first, then from GitHub.
+You will be asked for confirmation unless --yes is given.
+
+Example:
+  ghsecrets delete -k OLD_API_KEY -b aws
+  ghsecrets delete -k OLD_API_KEY --github-only
+  ghsecrets delete -k OLD_DEPLOY_KEY -b aws --environment production
+  ghsecrets delete -k OLD_API_KEY -b gcp --backup-only --yes`,
+	RunE: runDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().StringVarP(&deleteKey, "key", "k", "", "Secret key name to delete (required)")
+	deleteCmd.Flags().StringVarP(&deleteBackup, "backup", "b", "", "Backup to remove the key from (aws, gcp)")
+	deleteCmd.Flags().BoolVar(&deleteGitHubOnly, "github-only", false, "Only delete the secret from GitHub")
+	deleteCmd.Flags().BoolVar(&deleteBackupOnly, "backup-only", false, "Only remove the key from the backup")
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Skip the confirmation prompt")
+
+	deleteCmd.Flags().String("owner", "", "GitHub repository owner")
+	deleteCmd.Flags().String("repo", "", "GitHub repository name")
+	deleteCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to delete the secret from")
+	deleteCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	deleteCmd.Flags().String("aws-profile", "", "AWS profile name")
+	deleteCmd.Flags().String("gcp-project", "", "GCP project ID")
+
+	deleteCmd.MarkFlagRequired("key")
+	deleteCmd.MarkFlagsMutuallyExclusive("github-only", "backup-only")
+	deleteCmd.MarkFlagsMutuallyExclusive("github-only", "backup")
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if !deleteGitHubOnly && deleteBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp), or use --github-only")
+	}
+
+	var targets []string
+
+	// Resolve the backup first so that a key missing from it is reported before prompting
+	var store backupStore
+	var backupKeys []string
+	if !deleteGitHubOnly {
+		candidate, secretName, closeStore, err := openBackupStore(deleteBackup)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		// Only the names are needed, so encrypted values are not decrypted
+		keys, err := rawBackupStore(candidate).GetAllKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[deleteBackup], err)
+		}
+
+		if backupKeys = findBackupKeys(keys, deleteKey); len(backupKeys) > 0 {
+			store = candidate
+			targets = append(targets, fmt.Sprintf("%s secret '%s'", backupNames[deleteBackup], secretName))
+		} else if deleteBackupOnly {
+			return fmt.Errorf("key %s not found in %s secret '%s'", deleteKey, backupNames[deleteBackup], secretName)
+		} else {
+			fmt.Printf("Key '%s' not found in %s secret '%s', skipping backup\n", deleteKey, backupNames[deleteBackup], secretName)
+		}
+	}
+
+	var githubClient *github.Client
+	if !deleteBackupOnly {
+		client, githubOwner, githubRepo, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		githubClient = client
+		targets = append(targets, githubTargetName(githubOwner, githubRepo))
+	}
+
+	if !deleteYes {
+		ok, err := confirm(os.Stdin, fmt.Sprintf("Delete secret '%s' from %s? [y/N]: ", deleteKey, strings.Join(targets, " and ")))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	// Remove from the backup first so that a failed GitHub delete can simply be retried
+	if store != nil {
+		fmt.Printf("Removing secret '%s' from backup...\n", deleteKey)
+		for _, key := range backupKeys {
+			if err := store.RemoveKey(ctx, key); err != nil {
+				return fmt.Errorf("failed to remove from %s: %w", backupNames[deleteBackup], err)
+			}
+		}
+		fmt.Printf("✓ Successfully removed from %s\n", backupNames[deleteBackup])
+	}
+
+	if githubClient != nil {
+		fmt.Printf("Deleting secret '%s' from GitHub...\n", deleteKey)
+		err := githubClient.DeleteSecret(ctx, deleteKey)
+		switch {
+		case err == nil:
+			fmt.Println("✓ Successfully deleted from GitHub Secrets")
+		case store != nil && github.IsSecretNotFound(err):
+			// A secret that was only left in the backup is gone once the backup is cleaned up
+			fmt.Printf("Secret '%s' not found in GitHub, nothing to delete\n", deleteKey)
+		default:
+			return fmt.Errorf("failed to delete from GitHub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// findBackupKeys returns the backup keys that name the given secret or variable.
+// GitHub names are case-insensitive, so every spelling of the name is returned, sorted.
+func findBackupKeys(keys map[string]string, name string) []string {
+	var found []string
+	for key := range keys {
+		if strings.EqualFold(key, name) {
+			found = append(found, key)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// confirm prints the prompt and reports whether the answer read from r is yes
+func confirm(r io.Reader, prompt string) (bool, error) {
+	fmt.Print(prompt)
+
+	reader := bufio.NewReader(r)
+	answer, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
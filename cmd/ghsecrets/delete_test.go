@@ -0,0 +1,88 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/aws"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "yes", input: "yes\n", expected: true},
+		{name: "y", input: "y\n", expected: true},
+		{name: "upper case Y", input: "Y\n", expected: true},
+		{name: "no", input: "n\n", expected: false},
+		{name: "empty answer defaults to no", input: "\n", expected: false},
+		{name: "no trailing newline", input: "y", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := confirm(strings.NewReader(tt.input), "Delete? [y/N]: ")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/actions/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		// GitHub matches secret names case-insensitively
+		name := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/owner/repo/actions/secrets/")
+		if !strings.EqualFold(name, "API_KEY") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		deleted = append(deleted, name)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("aws.secret_name", "test-secret")
+	viper.Set("github.owner", "owner")
+	viper.Set("github.repo", "repo")
+	viper.Set("github.token", "test-token")
+	viper.Set("github.base_url", server.URL+"/api/v3/")
+	defer func() { deleteKey, deleteBackup, deleteGitHubOnly, deleteYes = "", "", false, false }()
+
+	mockAWS := useMockAWS(t)
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"sk-123","OLD_TOKEN":"x","DB_PASSWORD":"pw"}`, ""))
+
+	// The backup key is found whatever the case of the given name
+	deleteKey, deleteBackup, deleteYes = "api_key", "aws", true
+	require.NoError(t, runDelete(nil, nil))
+	assert.Equal(t, []string{"api_key"}, deleted)
+
+	// A key left only in the backup is removed from it without failing
+	deleteKey = "OLD_TOKEN"
+	require.NoError(t, runDelete(nil, nil))
+
+	keys, err := aws.NewJSONClient(mockAWS, "test-secret").GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "pw"}, keys)
+
+	// A secret missing from GitHub is still an error when the backup wasn't touched
+	deleteKey, deleteBackup, deleteGitHubOnly = "OLD_TOKEN", "", true
+	assert.EqualError(t, runDelete(nil, nil), "failed to delete from GitHub: secret OLD_TOKEN not found")
+}
@@ -0,0 +1,236 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+// Drift states of a key when comparing GitHub with a backup
+const (
+	driftInBoth       = "in both"
+	driftOnlyInGitHub = "only in GitHub"
+	driftOnlyInBackup = "only in backup"
+)
+
+// staleTolerance is how much newer GitHub's updated_at may be than the last write of
+// the backup before a key is flagged as stale. A push writes the backup first and GitHub
+// right after, so a correctly pushed key is always a little newer.
+const staleTolerance = 5 * time.Minute
+
+var (
+	diffBackup   string
+	diffFormat   string
+	diffExitCode bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:     "diff",
+	Aliases: []string{"status"},
+	Short:   "Compare GitHub secret names with the backup contents",
+	Long: `Compare the secrets of the GitHub repository, environment or organization
+with the keys in the backup.
+
+Each key is reported as:
+  in both         the key exists in GitHub and in the backup
+  only in GitHub  the key was never backed up (unbacked)
+  only in backup  the key is backed up but not deployed to GitHub
+
+Keys that exist in both are flagged as stale when GitHub's updated_at is more
+than 5 minutes newer than the last write of the backup. Secret values are never
+compared because GitHub does not expose them.
+
+Example:
+  ghsecrets diff -b aws
+  ghsecrets diff -b aws --environment production
+  ghsecrets diff -b aws --org my-org
+  ghsecrets diff -b gcp --format json
+  ghsecrets diff -b aws --exit-code  # Exit with an error if any drift is found`,
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffBackup, "backup", "b", "", "Backup source to compare with (aws, gcp)")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "table", "Output format: table or json")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with an error if any drift is found")
+
+	diffCmd.Flags().String("owner", "", "GitHub repository owner")
+	diffCmd.Flags().String("repo", "", "GitHub repository name")
+	diffCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to compare")
+	diffCmd.Flags().String("org", "", "GitHub organization to compare the organization secrets of")
+	diffCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	diffCmd.Flags().String("aws-profile", "", "AWS profile name")
+	diffCmd.Flags().String("gcp-project", "", "GCP project ID")
+}
+
+// driftEntry describes the state of a single key
+type driftEntry struct {
+	Key           string     `json:"key"`
+	Status        string     `json:"status"`
+	GitHubUpdated *time.Time `json:"github_updated_at,omitempty"`
+	Stale         bool       `json:"stale"`
+}
+
+// driftReport is the result of comparing GitHub with a backup
+type driftReport struct {
+	Target        string       `json:"target"`
+	Owner         string       `json:"owner"`
+	Repo          string       `json:"repo"`
+	Backend       string       `json:"backend"`
+	SecretName    string       `json:"secret_name"`
+	BackupUpdated time.Time    `json:"backup_updated_at"`
+	Entries       []driftEntry `json:"keys"`
+}
+
+// hasDrift reports whether any key is missing on one side or stale
+func (r driftReport) hasDrift() bool {
+	for _, entry := range r.Entries {
+		if entry.Status != driftInBoth || entry.Stale {
+			return true
+		}
+	}
+	return false
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if diffBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+	if diffFormat != "table" && diffFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be table or json)", diffFormat)
+	}
+
+	ctx := context.Background()
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	store, secretName, closeStore, err := openBackupStore(diffBackup)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	githubSecrets, err := githubClient.ListSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Only the names are compared, so encrypted values are not decrypted
+	backupKeys, err := rawBackupStore(store).GetAllKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[diffBackup], err)
+	}
+
+	backupUpdated, err := store.LastUpdated(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get last update time from %s: %w", backupNames[diffBackup], err)
+	}
+
+	report := driftReport{
+		Target:        githubTargetName(githubOwner, githubRepo),
+		Owner:         githubOwner,
+		Repo:          githubRepo,
+		Backend:       diffBackup,
+		SecretName:    secretName,
+		BackupUpdated: backupUpdated,
+		Entries:       compareSecrets(githubSecrets, backupKeys, backupUpdated),
+	}
+
+	if diffFormat == "json" {
+		err = writeJSON(os.Stdout, report)
+	} else {
+		err = writeDiffTable(os.Stdout, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if diffExitCode && report.hasDrift() {
+		return fmt.Errorf("drift detected between GitHub and %s", backupNames[diffBackup])
+	}
+	return nil
+}
+
+// compareSecrets joins the GitHub secret list with the backup keys.
+// GitHub stores secret names in upper case, so names are compared case-insensitively.
+func compareSecrets(githubSecrets []github.SecretInfo, backupKeys map[string]string, backupUpdated time.Time) []driftEntry {
+	backupByName := make(map[string]string, len(backupKeys))
+	for key := range backupKeys {
+		backupByName[strings.ToUpper(key)] = key
+	}
+
+	entries := make([]driftEntry, 0, len(githubSecrets)+len(backupKeys))
+	for _, secret := range githubSecrets {
+		updated := secret.UpdatedAt
+		entry := driftEntry{Key: secret.Name, GitHubUpdated: &updated}
+
+		if key, exists := backupByName[strings.ToUpper(secret.Name)]; exists {
+			entry.Key = key
+			entry.Status = driftInBoth
+			entry.Stale = secret.UpdatedAt.After(backupUpdated.Add(staleTolerance))
+			delete(backupByName, strings.ToUpper(secret.Name))
+		} else {
+			entry.Status = driftOnlyInGitHub
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, key := range backupByName {
+		entries = append(entries, driftEntry{Key: key, Status: driftOnlyInBackup})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+func writeDiffTable(w io.Writer, report driftReport) error {
+	fmt.Fprintf(w, "Comparing %s with %s secret '%s'\n", report.Target, backupNames[report.Backend], report.SecretName)
+	fmt.Fprintf(w, "Backup last written: %s\n\n", report.BackupUpdated.Format(time.RFC3339))
+
+	counts := make(map[string]int)
+	staleCount := 0
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSTATUS\tGITHUB UPDATED\tNOTE")
+	for _, entry := range report.Entries {
+		counts[entry.Status]++
+
+		updated := "-"
+		if entry.GitHubUpdated != nil {
+			updated = entry.GitHubUpdated.Format(time.RFC3339)
+		}
+
+		note := ""
+		switch {
+		case entry.Status == driftOnlyInGitHub:
+			note = "unbacked"
+		case entry.Status == driftOnlyInBackup:
+			note = "not deployed"
+		case entry.Stale:
+			note = "GitHub updated after last backup"
+			staleCount++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Key, entry.Status, updated, note)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d in both (%d stale), %d only in GitHub, %d only in backup\n",
+		counts[driftInBoth], staleCount, counts[driftOnlyInGitHub], counts[driftOnlyInBackup])
+	return nil
+}
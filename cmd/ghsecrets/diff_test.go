@@ -0,0 +1,88 @@
+package ghsecrets
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+func TestCompareSecrets(t *testing.T) {
+	backupUpdated := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	githubSecrets := []github.SecretInfo{
+		{Name: "API_KEY", UpdatedAt: backupUpdated.Add(-time.Hour)},
+		{Name: "TOKEN", UpdatedAt: backupUpdated.Add(time.Hour)},
+		{Name: "PUSHED", UpdatedAt: backupUpdated.Add(3 * time.Second)},
+		{Name: "WEB_UI_ONLY", UpdatedAt: backupUpdated},
+	}
+	backupKeys := map[string]string{
+		"API_KEY":      "value",
+		"PUSHED":       "value",
+		"token":        "value",
+		"NOT_DEPLOYED": "value",
+	}
+
+	entries := compareSecrets(githubSecrets, backupKeys, backupUpdated)
+	require.Len(t, entries, 5)
+
+	byKey := make(map[string]driftEntry)
+	for _, entry := range entries {
+		byKey[entry.Key] = entry
+	}
+
+	assert.Equal(t, driftInBoth, byKey["API_KEY"].Status)
+	assert.False(t, byKey["API_KEY"].Stale)
+
+	// GitHub upper-cases names, so the lower-case backup key still matches
+	assert.Equal(t, driftInBoth, byKey["token"].Status)
+	assert.True(t, byKey["token"].Stale)
+
+	// A push writes GitHub a few seconds after the backup, which is not stale
+	assert.Equal(t, driftInBoth, byKey["PUSHED"].Status)
+	assert.False(t, byKey["PUSHED"].Stale)
+
+	assert.Equal(t, driftOnlyInGitHub, byKey["WEB_UI_ONLY"].Status)
+	assert.Equal(t, driftOnlyInBackup, byKey["NOT_DEPLOYED"].Status)
+	assert.Nil(t, byKey["NOT_DEPLOYED"].GitHubUpdated)
+}
+
+func TestDriftReportHasDrift(t *testing.T) {
+	report := driftReport{Entries: []driftEntry{{Key: "API_KEY", Status: driftInBoth}}}
+	assert.False(t, report.hasDrift())
+
+	report.Entries = append(report.Entries, driftEntry{Key: "TOKEN", Status: driftInBoth, Stale: true})
+	assert.True(t, report.hasDrift())
+
+	report.Entries = []driftEntry{{Key: "TOKEN", Status: driftOnlyInGitHub}}
+	assert.True(t, report.hasDrift())
+}
+
+func TestWriteDiffTable(t *testing.T) {
+	backupUpdated := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	report := driftReport{
+		Target:        "environment 'production' of GitHub repository owner/repo",
+		Owner:         "owner",
+		Repo:          "repo",
+		Backend:       "aws",
+		SecretName:    "test-secret",
+		BackupUpdated: backupUpdated,
+		Entries: compareSecrets(
+			[]github.SecretInfo{{Name: "WEB_UI_ONLY", UpdatedAt: backupUpdated}},
+			map[string]string{"NOT_DEPLOYED": "value"},
+			backupUpdated,
+		),
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeDiffTable(buf, report))
+
+	output := buf.String()
+	assert.Contains(t, output, "Comparing environment 'production' of GitHub repository owner/repo with AWS Secrets Manager secret 'test-secret'")
+	assert.Contains(t, output, "unbacked")
+	assert.Contains(t, output, "not deployed")
+	assert.Contains(t, output, "0 in both (0 stale), 1 only in GitHub, 1 only in backup")
+}
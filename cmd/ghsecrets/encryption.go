@@ -0,0 +1,148 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/tom-023/ghsecrets/internal/crypt"
+	"golang.org/x/term"
+)
+
+// passphraseEnv holds the backup encryption passphrase for non-interactive use
+const passphraseEnv = "GHSECRETS_PASSPHRASE"
+
+// newSealer creates the sealer configured under encryption in ghsecrets.yaml,
+// or nil when backups are stored unencrypted
+func newSealer() (crypt.Sealer, error) {
+	switch mode := viper.GetString("encryption.mode"); mode {
+	case "":
+		return nil, nil
+
+	case "age":
+		recipients := viper.GetStringSlice("encryption.age_recipients")
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("encryption.age_recipients must be configured for age encryption")
+		}
+		identities := crypt.LoadAgeIdentities(viper.GetString("encryption.age_identity_file"))
+		return crypt.NewAgeSealer(recipients, identities)
+
+	case "passphrase":
+		return crypt.NewPassphraseSealer(readPassphrase), nil
+
+	default:
+		return nil, fmt.Errorf("invalid encryption mode: %s (must be age or passphrase)", mode)
+	}
+}
+
+// readPassphrase reads the encryption passphrase from GHSECRETS_PASSPHRASE or the terminal
+func readPassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("backup encryption passphrase required: set %s", passphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Enter backup encryption passphrase: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// sealedStore seals values written to a backup store and opens the values read from it
+type sealedStore struct {
+	backupStore
+	sealer crypt.Sealer
+}
+
+// AddOrUpdateKey seals the value and stores it
+func (s *sealedStore) AddOrUpdateKey(ctx context.Context, key, value string) error {
+	return s.AddOrUpdateKeys(ctx, map[string]string{key: value})
+}
+
+// AddOrUpdateKeys seals the values and stores them with a single write
+func (s *sealedStore) AddOrUpdateKeys(ctx context.Context, keys map[string]string) error {
+	if err := s.checkPassphrase(ctx); err != nil {
+		return err
+	}
+
+	sealed := make(map[string]string, len(keys))
+	for name, value := range keys {
+		sealedValue, err := s.sealer.Seal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
+		sealed[name] = sealedValue
+	}
+	return s.backupStore.AddOrUpdateKeys(ctx, sealed)
+}
+
+// GetKey returns the opened value of a key
+func (s *sealedStore) GetKey(ctx context.Context, key string) (string, error) {
+	value, err := s.backupStore.GetKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	opened, err := s.sealer.Open(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", key, err)
+	}
+	return opened, nil
+}
+
+// GetAllKeys returns the opened values of every key
+func (s *sealedStore) GetAllKeys(ctx context.Context) (map[string]string, error) {
+	keys, err := s.backupStore.GetAllKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := make(map[string]string, len(keys))
+	for name, value := range keys {
+		openedValue, err := s.sealer.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", name, err)
+		}
+		opened[name] = openedValue
+	}
+	return opened, nil
+}
+
+// checkPassphrase makes sure a mistyped passphrase doesn't seal new values with a
+// different key than the existing ones, by opening one of the sealed values first
+func (s *sealedStore) checkPassphrase(ctx context.Context) error {
+	if _, ok := s.sealer.(*crypt.PassphraseSealer); !ok {
+		return nil
+	}
+
+	existing, err := s.backupStore.GetAllKeys(ctx)
+	if err != nil {
+		// A missing backup has nothing to check against; the write reports other errors
+		return nil
+	}
+	for _, value := range existing {
+		if !crypt.IsSealed(value) {
+			continue
+		}
+		if _, err := s.sealer.Open(value); err != nil {
+			return fmt.Errorf("passphrase doesn't match the existing backup: %w", err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// rawBackupStore returns the store without encryption, for copying stored values as they are
+func rawBackupStore(store backupStore) backupStore {
+	if sealed, ok := store.(*sealedStore); ok {
+		return sealed.backupStore
+	}
+	return store
+}
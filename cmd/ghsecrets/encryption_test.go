@@ -0,0 +1,127 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/crypt"
+)
+
+func TestSealedStore(t *testing.T) {
+	ctx := context.Background()
+	mockAWS := aws.NewMockClient()
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, "test-secret", `{"LEGACY":"plain"}`, ""))
+	raw := aws.NewJSONClient(mockAWS, "test-secret")
+
+	passphrase := func() (string, error) { return "correct horse", nil }
+	store := &sealedStore{backupStore: raw, sealer: crypt.NewPassphraseSealer(passphrase)}
+	require.NoError(t, store.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "sk-123"}))
+
+	// The backend only sees the sealed value
+	stored, err := raw.GetKey(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.True(t, crypt.IsSealed(stored))
+
+	keys, err := store.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "sk-123", "LEGACY": "plain"}, keys)
+	assert.Equal(t, raw, rawBackupStore(store))
+
+	// A mistyped passphrase is refused before anything is written
+	wrong := &sealedStore{backupStore: raw, sealer: crypt.NewPassphraseSealer(func() (string, error) { return "wrong", nil })}
+	err = wrong.AddOrUpdateKey(ctx, "TOKEN", "abc")
+	assert.ErrorContains(t, err, "passphrase doesn't match the existing backup")
+	_, err = raw.GetKey(ctx, "TOKEN")
+	assert.Error(t, err)
+}
+
+func TestNewSealer(t *testing.T) {
+	defer viper.Reset()
+
+	sealer, err := newSealer()
+	require.NoError(t, err)
+	assert.Nil(t, sealer)
+
+	viper.Set("encryption.mode", "passphrase")
+	sealer, err = newSealer()
+	require.NoError(t, err)
+	assert.IsType(t, &crypt.PassphraseSealer{}, sealer)
+
+	viper.Set("encryption.mode", "age")
+	_, err = newSealer()
+	assert.EqualError(t, err, "encryption.age_recipients must be configured for age encryption")
+
+	viper.Set("encryption.mode", "kms")
+	_, err = newSealer()
+	assert.EqualError(t, err, "invalid encryption mode: kms (must be age or passphrase)")
+}
+
+func TestNameOnlyCommandsDontDecrypt(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count":1,"secrets":[{"name":"API_KEY","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	// Age encryption with only the recipient: values can be sealed but not opened
+	viper.Reset()
+	viper.Set("aws.secret_name", "test-secret")
+	viper.Set("github.owner", "owner")
+	viper.Set("github.repo", "repo")
+	viper.Set("github.token", "test-token")
+	viper.Set("github.base_url", server.URL+"/api/v3/")
+	viper.Set("encryption.mode", "age")
+	viper.Set("encryption.age_recipients", []string{identity.Recipient().String()})
+
+	mockAWS := useMockAWS(t)
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, "test-secret", "{}", ""))
+	store, _, closeStore, err := openBackupStore("aws")
+	require.NoError(t, err)
+	defer closeStore()
+	require.NoError(t, store.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "sk-123", "TOKEN": "abc"}))
+
+	listFormat, listShowValues = "table", false
+	assert.NoError(t, listBackup("aws"))
+
+	// Showing the values needs the identity
+	listShowValues = true
+	assert.ErrorContains(t, listBackup("aws"), "failed to decrypt")
+	listShowValues = false
+
+	diffBackup, diffFormat = "aws", "table"
+	assert.NoError(t, runDiff(nil, nil))
+
+	deleteKey, deleteBackup, deleteBackupOnly, deleteYes = "TOKEN", "aws", true, true
+	defer func() { deleteKey, deleteBackup, deleteBackupOnly, deleteYes = "", "", false, false }()
+	require.NoError(t, runDelete(nil, nil))
+
+	keys, err := rawBackupStore(store).GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, "API_KEY")
+}
+
+func TestBuildListedKeysEncrypted(t *testing.T) {
+	listed := buildListedKeys(map[string]string{"API_KEY": "ENC[age,abc]"}, false)
+	assert.Equal(t, []listedKey{{Name: "API_KEY", Encrypted: true}}, listed)
+
+	var buf strings.Builder
+	require.NoError(t, writeListTable(&buf, listedBackup{Backend: "aws", SecretName: "test-secret", Keys: listed}, false))
+	assert.Contains(t, buf.String(), "API_KEY  -       ********")
+}
@@ -0,0 +1,122 @@
+package ghsecrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/envfile"
+	"golang.org/x/term"
+)
+
+var (
+	exportBackup string
+	exportFormat string
+	exportKeys   []string
+	exportOutput string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export backed-up secrets to a dotenv, JSON, YAML or shell file",
+	Long: `Export the keys stored in the AWS Secrets Manager or GCP Secret Manager backup
+as dotenv, JSON, YAML or shell exports, with values quoted for the chosen format.
+
+Use --key to export only the keys matching glob patterns (for example 'DB_*').
+Patterns match case-insensitively, like the filters of restore.
+Files written with --output are created with 0600 permissions. Writing to a
+terminal prints a warning, since the values end up on screen.
+
+Example:
+  ghsecrets export -b aws --format dotenv -o .env
+  ghsecrets export -b gcp --format json --key 'DB_*' --key API_KEY
+  eval "$(ghsecrets export -b aws --format shell)"`,
+	RunE: runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportBackup, "backup", "b", "", "Backup source to export from (aws, gcp)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", envfile.FormatDotenv, "Output format: dotenv, json, yaml or shell")
+	exportCmd.Flags().StringSliceVarP(&exportKeys, "key", "k", nil, "Only export keys matching these glob patterns")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write (default: stdout)")
+
+	exportCmd.Flags().String("owner", "", "GitHub repository owner")
+	exportCmd.Flags().String("repo", "", "GitHub repository name")
+	exportCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to export the secrets of")
+	exportCmd.Flags().String("org", "", "GitHub organization to export the organization secrets of")
+	exportCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	exportCmd.Flags().String("aws-profile", "", "AWS profile name")
+	exportCmd.Flags().String("gcp-project", "", "GCP project ID")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+	if _, ok := backupNames[exportBackup]; !ok {
+		return fmt.Errorf("invalid backup source: %s (must be aws or gcp)", exportBackup)
+	}
+	if err := envfile.ValidateFormat(exportFormat); err != nil {
+		return err
+	}
+	filter, err := newKeyFilter(nil, exportKeys, nil, "")
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	store, _, closeStore, err := openBackupStore(exportBackup)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	keys, err := store.GetAllKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[exportBackup], err)
+	}
+
+	keys = filter.apply(keys)
+
+	var buf bytes.Buffer
+	if err := envfile.Write(&buf, exportFormat, keys); err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Fprintln(os.Stderr, "Warning: writing secret values to the terminal. Use --output to write them to a file instead.")
+		}
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	if err := writePrivateFile(exportOutput, buf.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ Exported %d secrets from %s to %s\n", len(keys), backupNames[exportBackup], exportOutput)
+	return nil
+}
+
+// writePrivateFile writes data to a file readable only by the current user,
+// tightening the permissions of an existing file as well
+func writePrivateFile(name string, data []byte) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if err := file.Chmod(0600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of %s: %w", name, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,63 @@
+package ghsecrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRejectsInvalidFormat(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Reset()
+	viper.Set("aws.secret_name", "test-secret")
+	defer func() { exportBackup, exportFormat = "", "dotenv" }()
+
+	// The format is checked before the backup is read
+	mockAWS := useMockAWS(t)
+	mockAWS.SetError("GetSecret", fmt.Errorf("backup must not be read"))
+
+	exportBackup, exportFormat = "aws", "yml"
+	assert.EqualError(t, runExport(nil, nil), "invalid format: yml (must be dotenv, json, yaml or shell)")
+}
+
+func TestFilterKeys(t *testing.T) {
+	keys := map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p", "API_KEY": "k", "TOKEN": "t"}
+
+	filter, err := newKeyFilter(nil, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, keys, filter.apply(keys))
+
+	filter, err = newKeyFilter(nil, []string{"DB_*", "API_KEY"}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p", "API_KEY": "k"}, filter.apply(keys))
+
+	// --key patterns match case-insensitively like the filters of restore
+	filter, err = newKeyFilter(nil, []string{"db_*"}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p"}, filter.apply(keys))
+
+	_, err = newKeyFilter(nil, []string{"DB_["}, nil, "")
+	assert.ErrorContains(t, err, `invalid key pattern "DB_["`)
+}
+
+func TestWritePrivateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), ".env")
+
+	// An existing world-readable file is tightened to 0600
+	require.NoError(t, os.WriteFile(name, []byte("old"), 0644))
+	require.NoError(t, writePrivateFile(name, []byte("API_KEY=\"value\"\n")))
+
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "API_KEY=\"value\"\n", string(data))
+}
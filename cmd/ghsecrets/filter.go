@@ -0,0 +1,102 @@
+package ghsecrets
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// keyFilter selects the keys restore and export work on. Names are compared
+// case-insensitively, as GitHub stores them upper-cased.
+type keyFilter struct {
+	names   []string
+	include []string
+	exclude []string
+	match   *regexp.Regexp
+}
+
+// newKeyFilter validates the glob patterns and the regular expression of a filter
+func newKeyFilter(names, include, exclude []string, match string) (*keyFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %w", pattern, err)
+		}
+	}
+
+	filter := &keyFilter{names: names, include: include, exclude: exclude}
+	if match != "" {
+		re, err := regexp.Compile("(?i)" + match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match expression: %w", err)
+		}
+		filter.match = re
+	}
+	return filter, nil
+}
+
+// empty reports whether the filter keeps every key
+func (f *keyFilter) empty() bool {
+	return len(f.names) == 0 && len(f.include) == 0 && len(f.exclude) == 0 && f.match == nil
+}
+
+// selects reports whether a key passes the filter. Without --key, --include or --match
+// every key is selected unless it is excluded.
+func (f *keyFilter) selects(name string) bool {
+	for _, pattern := range f.exclude {
+		if matchesKey(pattern, name) {
+			return false
+		}
+	}
+
+	if len(f.names) == 0 && len(f.include) == 0 && f.match == nil {
+		return true
+	}
+	for _, key := range f.names {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	for _, pattern := range f.include {
+		if matchesKey(pattern, name) {
+			return true
+		}
+	}
+	return f.match != nil && f.match.MatchString(name)
+}
+
+// apply returns the keys selected by the filter
+func (f *keyFilter) apply(keys map[string]string) map[string]string {
+	selected := make(map[string]string)
+	for name, value := range keys {
+		if f.selects(name) {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+// missing returns the names given with --key that aren't in any of the key sets
+func (f *keyFilter) missing(sets ...map[string]string) []string {
+	var missing []string
+	for _, key := range f.names {
+		found := false
+		for _, keys := range sets {
+			for name := range keys {
+				if strings.EqualFold(key, name) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// matchesKey matches a glob pattern against a key name case-insensitively
+func matchesKey(pattern, name string) bool {
+	matched, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name))
+	return matched
+}
@@ -0,0 +1,61 @@
+package ghsecrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyFilter(t *testing.T) {
+	keys := map[string]string{
+		"STRIPE_KEY":            "k",
+		"STRIPE_WEBHOOK_SECRET": "w",
+		"API_KEY":               "a",
+		"DB_PASSWORD":           "p",
+	}
+
+	tests := []struct {
+		name     string
+		names    []string
+		include  []string
+		exclude  []string
+		match    string
+		expected []string
+	}{
+		{name: "no filters", expected: []string{"API_KEY", "DB_PASSWORD", "STRIPE_KEY", "STRIPE_WEBHOOK_SECRET"}},
+		{name: "single key", names: []string{"api_key"}, expected: []string{"API_KEY"}},
+		{name: "include glob", include: []string{"STRIPE_*"}, expected: []string{"STRIPE_KEY", "STRIPE_WEBHOOK_SECRET"}},
+		{name: "include and exclude", include: []string{"STRIPE_*"}, exclude: []string{"*_SECRET"}, expected: []string{"STRIPE_KEY"}},
+		{name: "exclude only", exclude: []string{"STRIPE_*"}, expected: []string{"API_KEY", "DB_PASSWORD"}},
+		{name: "regex", match: "^(API|DB)_", expected: []string{"API_KEY", "DB_PASSWORD"}},
+		{name: "lower-case glob", include: []string{"stripe_*"}, exclude: []string{"*_secret"}, expected: []string{"STRIPE_KEY"}},
+		{name: "lower-case regex", match: "^db_", expected: []string{"DB_PASSWORD"}},
+		{name: "key or glob", names: []string{"DB_PASSWORD"}, include: []string{"API_*"}, expected: []string{"API_KEY", "DB_PASSWORD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newKeyFilter(tt.names, tt.include, tt.exclude, tt.match)
+			require.NoError(t, err)
+
+			var selected []string
+			for name := range filter.apply(keys) {
+				selected = append(selected, name)
+			}
+			assert.ElementsMatch(t, tt.expected, selected)
+		})
+	}
+}
+
+func TestKeyFilterErrors(t *testing.T) {
+	_, err := newKeyFilter(nil, []string{"STRIPE_["}, nil, "")
+	assert.ErrorContains(t, err, `invalid key pattern "STRIPE_["`)
+
+	_, err = newKeyFilter(nil, nil, nil, "(")
+	assert.ErrorContains(t, err, "invalid --match expression")
+
+	filter, err := newKeyFilter([]string{"API_KEY", "MISSING"}, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MISSING"}, filter.missing(map[string]string{"API_KEY": "a"}, map[string]string{"REGION": "r"}))
+}
@@ -0,0 +1,114 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/tom-023/ghsecrets/internal/auth"
+	"github.com/tom-023/ghsecrets/internal/github"
+	"golang.org/x/oauth2"
+)
+
+// newGitHubClient creates a GitHub client for the target given by flags or config:
+// an organization (--org), an Actions environment (--environment) or a repository.
+// For an organization the returned owner is the organization and repo is empty.
+// github.base_url and github.upload_url select a GitHub Enterprise Server, and
+// github.app authenticates as a GitHub App installation instead of with a token.
+func newGitHubClient() (*github.Client, string, string, error) {
+	return newGitHubClientFor(
+		viper.GetString("github.org"),
+		viper.GetString("github.owner"),
+		viper.GetString("github.repo"),
+		viper.GetString("github.environment"),
+	)
+}
+
+// newGitHubClientFor is like newGitHubClient for a target other than the configured one
+func newGitHubClientFor(githubOrg, githubOwner, githubRepo, githubEnvironment string) (*github.Client, string, string, error) {
+	if githubOrg != "" && githubEnvironment != "" {
+		return nil, "", "", fmt.Errorf("--org cannot be combined with --environment")
+	}
+	if githubOrg == "" && (githubOwner == "" || githubRepo == "") {
+		return nil, "", "", fmt.Errorf("GitHub owner and repo must be specified")
+	}
+
+	opts := github.ClientOptions{
+		BaseURL:   viper.GetString("github.base_url"),
+		UploadURL: viper.GetString("github.upload_url"),
+	}
+
+	// A configured GitHub App takes the place of personal tokens
+	var githubToken string
+	if viper.GetInt64("github.app.id") != 0 {
+		tokenSource, err := newAppTokenSource(githubOrg, githubOwner, githubRepo, opts)
+		if err != nil {
+			return nil, "", "", err
+		}
+		opts.TokenSource = tokenSource
+	} else {
+		var err error
+		githubToken, err = auth.GetGitHubTokenForHost(viper.GetString("github.token"), opts.Hostname())
+		if err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	if githubOrg != "" {
+		client, err := github.NewOrgClientWithOptions(githubToken, githubOrg, opts)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return client, githubOrg, "", nil
+	}
+
+	client, err := github.NewClientWithOptions(githubToken, githubOwner, githubRepo, opts)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client.WithEnvironment(githubEnvironment), githubOwner, githubRepo, nil
+}
+
+// newAppTokenSource creates the installation token source of the GitHub App configured
+// under github.app, discovering the installation on the target when no ID is configured
+func newAppTokenSource(githubOrg, githubOwner, githubRepo string, opts github.ClientOptions) (oauth2.TokenSource, error) {
+	keyPath := viper.GetString("github.app.private_key_path")
+	if keyPath == "" {
+		return nil, fmt.Errorf("github.app.private_key_path must be configured for GitHub App authentication")
+	}
+
+	privateKey, err := auth.LoadAppPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	app := github.AppAuth{
+		AppID:          viper.GetInt64("github.app.id"),
+		InstallationID: viper.GetInt64("github.app.installation_id"),
+		PrivateKey:     privateKey,
+	}
+
+	if githubOrg != "" {
+		return github.NewAppTokenSource(context.Background(), app, githubOrg, "", opts)
+	}
+	return github.NewAppTokenSource(context.Background(), app, githubOwner, githubRepo, opts)
+}
+
+// githubTargetName describes the organization, repository or environment the commands write to
+func githubTargetName(githubOwner, githubRepo string) string {
+	if githubOrg := viper.GetString("github.org"); githubOrg != "" {
+		return fmt.Sprintf("GitHub organization %s", githubOrg)
+	}
+	if environment := viper.GetString("github.environment"); environment != "" {
+		return fmt.Sprintf("environment '%s' of GitHub repository %s/%s", environment, githubOwner, githubRepo)
+	}
+	return fmt.Sprintf("GitHub repository %s/%s", githubOwner, githubRepo)
+}
+
+// storeTargetName describes the GitHub target including the secret store when it isn't Actions
+func storeTargetName(store github.Store, githubOwner, githubRepo string) string {
+	if store == github.StoreActions {
+		return githubTargetName(githubOwner, githubRepo)
+	}
+	return fmt.Sprintf("%s secrets of %s", store, githubTargetName(githubOwner, githubRepo))
+}
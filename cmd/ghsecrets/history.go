@@ -0,0 +1,124 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+)
+
+var (
+	historyBackup string
+	historyLimit  int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the stored versions of the backup",
+	Long: `List the versions of the backup secret kept by AWS Secrets Manager or GCP
+Secret Manager, newest first. Every push writes a new version of the whole backup.
+
+Pass a version ID to 'ghsecrets restore --version', or a time to
+'ghsecrets restore --at', to restore the secrets as they were back then.
+
+Example:
+  ghsecrets history -b aws
+  ghsecrets history -b gcp --limit 5`,
+	RunE: runHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVarP(&historyBackup, "backup", "b", "", "Backup source to list the versions of (aws, gcp)")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "Maximum number of versions to list (0 for all)")
+
+	historyCmd.Flags().String("owner", "", "GitHub repository owner")
+	historyCmd.Flags().String("repo", "", "GitHub repository name")
+	historyCmd.Flags().String("org", "", "GitHub organization whose backup to list")
+	historyCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	historyCmd.Flags().String("aws-profile", "", "AWS profile name")
+	historyCmd.Flags().String("gcp-project", "", "GCP project ID")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+	if _, ok := backupNames[historyBackup]; !ok {
+		return fmt.Errorf("invalid backup source: %s (must be aws or gcp)", historyBackup)
+	}
+
+	ctx := context.Background()
+
+	secret, err := openBackupSecret(historyBackup, "")
+	if err != nil {
+		return err
+	}
+	defer secret.close()
+
+	versions, err := secret.versions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list versions in %s: %w", backupNames[historyBackup], err)
+	}
+
+	target := fmt.Sprintf("%s secret '%s'", backupNames[historyBackup], secret.name)
+	return writeHistoryTable(os.Stdout, target, versions, historyLimit)
+}
+
+// writeHistoryTable prints the versions, newest first, marking the current one
+func writeHistoryTable(w io.Writer, target string, versions []bundle.Version, limit int) error {
+	fmt.Fprintf(w, "%s (%d versions)\n\n", target, len(versions))
+	if len(versions) == 0 {
+		return nil
+	}
+	if limit > 0 && len(versions) > limit {
+		versions = versions[:limit]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tCREATED\tCURRENT")
+	for _, version := range versions {
+		current := ""
+		if version.Current {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", version.ID, version.Created.Format(time.RFC3339), current)
+	}
+	return tw.Flush()
+}
+
+// resolveBackupVersion returns the version ID of the named backup secret to restore from --version or --at,
+// or "" for the current version
+func resolveBackupVersion(ctx context.Context, backend, secretName, versionID, at string) (string, error) {
+	if at == "" {
+		return versionID, nil
+	}
+
+	atTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return "", fmt.Errorf("invalid --at time %q (must be RFC3339, for example 2024-05-01T09:00:00Z)", at)
+	}
+
+	secret, err := openNamedBackupSecret(backend, secretName, "")
+	if err != nil {
+		return "", err
+	}
+	defer secret.close()
+
+	versions, err := secret.versions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list versions in %s: %w", backupNames[backend], err)
+	}
+
+	version, ok := bundle.VersionAt(versions, atTime)
+	if !ok {
+		return "", fmt.Errorf("no version of %s secret '%s' existed at %s", backupNames[backend], secret.name, atTime.Format(time.RFC3339))
+	}
+	return version.ID, nil
+}
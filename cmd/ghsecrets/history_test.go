@@ -0,0 +1,41 @@
+package ghsecrets
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+)
+
+func TestWriteHistoryTable(t *testing.T) {
+	versions := []bundle.Version{
+		{ID: "3", Created: time.Date(2024, 5, 3, 9, 0, 0, 0, time.UTC), Current: true},
+		{ID: "2", Created: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{ID: "1", Created: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	var buf strings.Builder
+	require.NoError(t, writeHistoryTable(&buf, "GCP Secret Manager secret 'backup'", versions, 2))
+	assert.Equal(t, `GCP Secret Manager secret 'backup' (3 versions)
+
+VERSION  CREATED               CURRENT
+3        2024-05-03T09:00:00Z  *
+2        2024-05-02T09:00:00Z  
+`, buf.String())
+}
+
+func TestResolveBackupVersion(t *testing.T) {
+	ctx := context.Background()
+
+	// --version is used as is, without reading the backup
+	versionID, err := resolveBackupVersion(ctx, "aws", "test-secret", "abc", "")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", versionID)
+
+	_, err = resolveBackupVersion(ctx, "aws", "test-secret", "", "yesterday")
+	assert.EqualError(t, err, `invalid --at time "yesterday" (must be RFC3339, for example 2024-05-01T09:00:00Z)`)
+}
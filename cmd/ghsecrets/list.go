@@ -1,73 +1,258 @@
 package ghsecrets
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"github.com/tom-023/ghsecrets/internal/aws"
-	"github.com/tom-023/ghsecrets/internal/gcp"
+	"github.com/tom-023/ghsecrets/internal/crypt"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+const maskedValue = "********"
+
+var (
+	listShowValues bool
+	listFormat     string
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
-	Short: "List secrets from cloud providers",
-	Long: `List secrets stored in AWS Secrets Manager or GCP Secret Manager.
+	Short: "List secrets from GitHub or cloud providers",
+	Long: `List secrets stored in AWS Secrets Manager, GCP Secret Manager or GitHub.
+
+Values are masked unless --show-values is given.
 Note: GitHub API does not support listing secret values, only secret names.`,
 }
 
+var listGitHubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "List secret names from the GitHub repository",
+	Long: `List the secret names of the GitHub repository with their creation
+and last update timestamps. GitHub never returns secret values.
+
+Example:
+  ghsecrets list github
+  ghsecrets list github --owner owner --repo repo --format json
+  ghsecrets list github --org my-org`,
+	RunE: runListGitHub,
+}
+
 var listAWSCmd = &cobra.Command{
 	Use:   "aws",
 	Short: "List secrets from AWS Secrets Manager",
-	RunE:  runListAWS,
+	Long: `List the keys stored in the AWS Secrets Manager backup bundle.
+
+Example:
+  ghsecrets list aws
+  ghsecrets list aws --format json
+  ghsecrets list aws --show-values`,
+	RunE: runListAWS,
 }
 
 var listGCPCmd = &cobra.Command{
 	Use:   "gcp",
 	Short: "List secrets from GCP Secret Manager",
-	RunE:  runListGCP,
+	Long: `List the keys stored in the GCP Secret Manager backup bundle.
+
+Example:
+  ghsecrets list gcp --gcp-project my-project
+  ghsecrets list gcp --format json`,
+	RunE: runListGCP,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.AddCommand(listAWSCmd)
 	listCmd.AddCommand(listGCPCmd)
+	listCmd.AddCommand(listGitHubCmd)
+
+	listCmd.PersistentFlags().BoolVar(&listShowValues, "show-values", false, "Show secret values instead of masking them")
+	listCmd.PersistentFlags().StringVar(&listFormat, "format", "table", "Output format: table or json")
+
+	listCmd.PersistentFlags().String("aws-region", "us-east-1", "AWS region")
+	listCmd.PersistentFlags().String("aws-profile", "", "AWS profile name")
+	listCmd.PersistentFlags().String("gcp-project", "", "GCP project ID")
+	listCmd.PersistentFlags().String("org", "", "GitHub organization to list organization secrets of")
+
+	listGitHubCmd.Flags().String("owner", "", "GitHub repository owner")
+	listGitHubCmd.Flags().String("repo", "", "GitHub repository name")
+}
+
+// listedKey is a single entry of the list output
+type listedKey struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+	Value  string `json:"value,omitempty"`
+	// Encrypted is set for values listed without decrypting them, whose length is unknown
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+// listedBackup is the JSON representation of a backup bundle listing
+type listedBackup struct {
+	Backend    string      `json:"backend"`
+	SecretName string      `json:"secret_name"`
+	Keys       []listedKey `json:"keys"`
+}
+
+// listedRepository is the JSON representation of a GitHub repository or organization listing
+type listedRepository struct {
+	Org     string              `json:"org,omitempty"`
+	Owner   string              `json:"owner,omitempty"`
+	Repo    string              `json:"repo,omitempty"`
+	Secrets []github.SecretInfo `json:"secrets"`
 }
 
 func runListAWS(cmd *cobra.Command, args []string) error {
-	awsRegion := viper.GetString("aws.region")
-	if awsRegion == "" {
-		awsRegion = "us-east-1"
+	return listBackup("aws")
+}
+
+func runListGCP(cmd *cobra.Command, args []string) error {
+	return listBackup("gcp")
+}
+
+func runListGitHub(cmd *cobra.Command, args []string) error {
+	if listFormat != "table" && listFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be table or json)", listFormat)
+	}
+
+	ctx := context.Background()
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
 	}
 
-	_, err := aws.NewClient(awsRegion)
+	secrets, err := githubClient.ListSecrets(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS client: %w", err)
+		return err
 	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
 
-	// Note: AWS SDK doesn't have a simple list secrets method in the current implementation
-	// This would need to be implemented in the AWS client
-	fmt.Println("AWS Secrets Manager listing functionality to be implemented")
+	listed := listedRepository{Secrets: secrets}
+	if githubClient.IsOrg() {
+		listed.Org = githubOwner
+	} else {
+		listed.Owner = githubOwner
+		listed.Repo = githubRepo
+	}
 
-	return nil
+	if listFormat == "json" {
+		return writeJSON(os.Stdout, listed)
+	}
+	return writeGitHubListTable(os.Stdout, listed)
 }
 
-func runListGCP(cmd *cobra.Command, args []string) error {
-	gcpProject := viper.GetString("gcp.project")
-	if gcpProject == "" {
-		return fmt.Errorf("GCP project ID not specified. Use --gcp-project flag or configure in .ghsecrets.yaml")
+// listBackup prints the keys of the configured backup bundle of the backend
+func listBackup(backend string) error {
+	if listFormat != "table" && listFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be table or json)", listFormat)
 	}
 
-	gcpCreds := viper.GetString("gcp.credentials_path")
+	ctx := context.Background()
 
-	gcpClient, err := gcp.NewClient(gcpProject, gcpCreds)
+	store, secretName, closeStore, err := openBackupStore(backend)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	// Masked listings don't need the values, so encrypted values are only decrypted to show them
+	if !listShowValues {
+		store = rawBackupStore(store)
+	}
+	keys, err := store.GetAllKeys(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create GCP client: %w", err)
+		return fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[backend], err)
 	}
-	defer gcpClient.Close()
 
-	// Note: GCP SDK listing functionality would need to be implemented in the GCP client
-	fmt.Println("GCP Secret Manager listing functionality to be implemented")
+	listed := listedBackup{
+		Backend:    backend,
+		SecretName: secretName,
+		Keys:       buildListedKeys(keys, listShowValues),
+	}
+
+	if listFormat == "json" {
+		return writeJSON(os.Stdout, listed)
+	}
+	return writeListTable(os.Stdout, listed, listShowValues)
+}
+
+// buildListedKeys converts the bundle into sorted list entries, masking values unless showValues is set
+func buildListedKeys(keys map[string]string, showValues bool) []listedKey {
+	listed := make([]listedKey, 0, len(keys))
+	for name, value := range keys {
+		entry := listedKey{Name: name, Length: utf8.RuneCountInString(value)}
+		if crypt.IsSealed(value) {
+			entry = listedKey{Name: name, Encrypted: true}
+		}
+		if showValues {
+			entry.Value = value
+		}
+		listed = append(listed, entry)
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+	return listed
+}
 
-	return nil
+func writeJSON(w io.Writer, listed interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(listed)
+}
+
+func writeListTable(w io.Writer, listed listedBackup, showValues bool) error {
+	fmt.Fprintf(w, "Backup: %s secret '%s' (%d keys)\n\n", backupNames[listed.Backend], listed.SecretName, len(listed.Keys))
+	if len(listed.Keys) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tLENGTH\tVALUE")
+	for _, entry := range listed.Keys {
+		value := entry.Value
+		if !showValues {
+			value = maskedValue
+		}
+		length := strconv.Itoa(entry.Length)
+		if entry.Encrypted {
+			length = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.Name, length, value)
+	}
+	return tw.Flush()
+}
+
+func writeGitHubListTable(w io.Writer, listed listedRepository) error {
+	if listed.Org != "" {
+		fmt.Fprintf(w, "GitHub organization %s (%d secrets)\n\n", listed.Org, len(listed.Secrets))
+	} else {
+		fmt.Fprintf(w, "GitHub repository %s/%s (%d secrets)\n\n", listed.Owner, listed.Repo, len(listed.Secrets))
+	}
+	if len(listed.Secrets) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if listed.Org != "" {
+		fmt.Fprintln(tw, "NAME\tVISIBILITY\tCREATED\tUPDATED")
+		for _, secret := range listed.Secrets {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", secret.Name, secret.Visibility, secret.CreatedAt.Format(time.RFC3339), secret.UpdatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+
+	fmt.Fprintln(tw, "NAME\tCREATED\tUPDATED")
+	for _, secret := range listed.Secrets {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", secret.Name, secret.CreatedAt.Format(time.RFC3339), secret.UpdatedAt.Format(time.RFC3339))
+	}
+	return tw.Flush()
 }
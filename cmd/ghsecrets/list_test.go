@@ -0,0 +1,109 @@
+package ghsecrets
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+func TestBuildListedKeys(t *testing.T) {
+	keys := map[string]string{
+		"TOKEN":   "bearer-token",
+		"API_KEY": "secret",
+	}
+
+	// Values are masked by default and entries are sorted by name
+	listed := buildListedKeys(keys, false)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "API_KEY", listed[0].Name)
+	assert.Equal(t, 6, listed[0].Length)
+	assert.Empty(t, listed[0].Value)
+	assert.Equal(t, "TOKEN", listed[1].Name)
+
+	// Values are only included when explicitly requested
+	listed = buildListedKeys(keys, true)
+	assert.Equal(t, "secret", listed[0].Value)
+	assert.Equal(t, "bearer-token", listed[1].Value)
+}
+
+func TestWriteListTable(t *testing.T) {
+	listed := listedBackup{
+		Backend:    "aws",
+		SecretName: "test-secret",
+		Keys:       buildListedKeys(map[string]string{"API_KEY": "secret"}, false),
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeListTable(buf, listed, false))
+
+	output := buf.String()
+	assert.Contains(t, output, "AWS Secrets Manager secret 'test-secret' (1 keys)")
+	assert.Contains(t, output, "API_KEY")
+	assert.Contains(t, output, maskedValue)
+	assert.NotContains(t, output, "secret\n")
+}
+
+func TestWriteListJSON(t *testing.T) {
+	listed := listedBackup{
+		Backend:    "gcp",
+		SecretName: "test-secret",
+		Keys:       buildListedKeys(map[string]string{"API_KEY": "secret"}, false),
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeJSON(buf, listed))
+
+	output := buf.String()
+	assert.Contains(t, output, `"backend": "gcp"`)
+	assert.Contains(t, output, `"name": "API_KEY"`)
+	assert.NotContains(t, output, `"value"`)
+}
+
+func TestWriteGitHubListTable(t *testing.T) {
+	listed := listedRepository{
+		Owner: "owner",
+		Repo:  "repo",
+		Secrets: []github.SecretInfo{
+			{
+				Name:      "API_KEY",
+				CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeGitHubListTable(buf, listed))
+
+	output := buf.String()
+	assert.Contains(t, output, "GitHub repository owner/repo (1 secrets)")
+	assert.Contains(t, output, "API_KEY")
+	assert.Contains(t, output, "2024-01-01T00:00:00Z")
+	assert.Contains(t, output, "2024-02-01T00:00:00Z")
+}
+
+func TestWriteGitHubListTableOrg(t *testing.T) {
+	listed := listedRepository{
+		Org: "my-org",
+		Secrets: []github.SecretInfo{
+			{
+				Name:       "NPM_TOKEN",
+				Visibility: "selected",
+				CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeGitHubListTable(buf, listed))
+
+	output := buf.String()
+	assert.Contains(t, output, "GitHub organization my-org (1 secrets)")
+	assert.Contains(t, output, "VISIBILITY")
+	assert.Contains(t, output, "selected")
+}
@@ -0,0 +1,109 @@
+package ghsecrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tom-023/ghsecrets/internal/gcp"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+// planAction is the change a dry run predicts for a single key
+type planAction string
+
+const (
+	planCreate planAction = "create"
+	planUpdate planAction = "update"
+	planNoop   planAction = "no-op"
+)
+
+var planSymbols = map[planAction]string{
+	planCreate: "+",
+	planUpdate: "~",
+	planNoop:   "=",
+}
+
+// planKeys predicts the action for each desired key given the current keys of a target.
+// GitHub secret values can't be read, so no-op is only predicted when compareValues is set.
+// Names are matched case-insensitively as GitHub stores them upper-cased.
+func planKeys(desired, current map[string]string, compareValues bool) map[string]planAction {
+	currentByName := make(map[string]string, len(current))
+	for name, value := range current {
+		currentByName[strings.ToUpper(name)] = value
+	}
+
+	plan := make(map[string]planAction, len(desired))
+	for name, value := range desired {
+		currentValue, exists := currentByName[strings.ToUpper(name)]
+		switch {
+		case !exists:
+			plan[name] = planCreate
+		case compareValues && currentValue == value:
+			plan[name] = planNoop
+		default:
+			plan[name] = planUpdate
+		}
+	}
+	return plan
+}
+
+// writePlan prints the planned action of every key followed by a summary line
+func writePlan(w io.Writer, target, kind string, plan map[string]planAction) {
+	names := make([]string, 0, len(plan))
+	counts := make(map[planAction]int)
+	for name, action := range plan {
+		names = append(names, name)
+		counts[action]++
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "Plan for %s:\n", target)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s %s (%s)\n", planSymbols[plan[name]], kind, name, plan[name])
+	}
+	fmt.Fprintf(w, "%d to create, %d to update, %d unchanged\n\n", counts[planCreate], counts[planUpdate], counts[planNoop])
+}
+
+// githubSecretNames returns the names of the secrets that currently exist in GitHub
+func githubSecretNames(ctx context.Context, githubClient *github.Client) (map[string]string, error) {
+	secrets, err := githubClient.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		names[secret.Name] = ""
+	}
+	return names, nil
+}
+
+// githubVariableValues returns the variables that currently exist in GitHub with their values
+func githubVariableValues(ctx context.Context, githubClient *github.Client) (map[string]string, error) {
+	variables, err := githubClient.ListVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		values[variable.Name] = variable.Value
+	}
+	return values, nil
+}
+
+// currentBackupKeys reads the keys of a backup section for a dry run.
+// A GCP secret that doesn't exist yet is empty, since the first push creates it.
+func currentBackupKeys(ctx context.Context, backend string, store backupStore) (map[string]string, error) {
+	keys, err := store.GetAllKeys(ctx)
+	if err != nil {
+		if backend == "gcp" && gcp.IsSecretNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[backend], err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,43 @@
+package ghsecrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanKeys(t *testing.T) {
+	desired := map[string]string{"api_key": "new", "TOKEN": "same", "NEW_KEY": "value"}
+	current := map[string]string{"API_KEY": "old", "TOKEN": "same"}
+
+	assert.Equal(t, map[string]planAction{
+		"api_key": planUpdate,
+		"TOKEN":   planNoop,
+		"NEW_KEY": planCreate,
+	}, planKeys(desired, current, true))
+
+	// Without readable values every existing key is an update
+	assert.Equal(t, map[string]planAction{
+		"api_key": planUpdate,
+		"TOKEN":   planUpdate,
+		"NEW_KEY": planCreate,
+	}, planKeys(desired, current, false))
+}
+
+func TestWritePlan(t *testing.T) {
+	var buf strings.Builder
+	writePlan(&buf, "owner/repo", "secret", map[string]planAction{
+		"TOKEN":   planNoop,
+		"API_KEY": planUpdate,
+		"NEW_KEY": planCreate,
+	})
+
+	assert.Equal(t, `Plan for owner/repo:
+  ~ secret API_KEY (update)
+  + secret NEW_KEY (create)
+  = secret TOKEN (no-op)
+1 to create, 1 to update, 1 unchanged
+
+`, buf.String())
+}
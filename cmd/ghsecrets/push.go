@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/tom-023/ghsecrets/internal/auth"
-	"github.com/tom-023/ghsecrets/internal/aws"
-	"github.com/tom-023/ghsecrets/internal/gcp"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/envfile"
 	"github.com/tom-023/ghsecrets/internal/github"
 	"golang.org/x/term"
 )
@@ -26,22 +26,57 @@ var (
 	region     string
 	awsProfile string
 	project    string
+
+	visibility    string
+	selectedRepos []string
+	pushStore     string
+
+	fromEnvFile string
+	fromJSON    string
+	pushDryRun  bool
+	noRollback  bool
 )
 
 var pushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Push a secret to GitHub and optionally backup to cloud",
 	Long: `Push a secret to GitHub Secrets and optionally backup to
-AWS Secrets Manager.
+AWS Secrets Manager or GCP Secret Manager.
+
+Use --environment to push to an Actions environment instead of the repository.
+Environment secrets are kept in their own section of the backup.
+
+Use --org to push an organization secret. --visibility controls which
+repositories can use it (all, private or selected); --selected-repos lists the
+repositories for 'selected'. Existing organization secrets keep their
+visibility unless --visibility is given, new ones default to private.
+
+Use --from-env-file or --from-json to push every entry of a dotenv file or a
+JSON object at once. All entries are backed up with a single write of the
+backup, then pushed to GitHub one by one with a per-key summary.
+
+If pushing to GitHub fails after the backup was written, the previous backup
+values are restored so the backup never holds a value that was not deployed.
+Use --no-rollback to keep the new backup values instead.
+
+Use --dry-run to print the planned change for the backup and GitHub (create,
+update or no-op per key) without writing anything.
+
+Use --store to push to the Dependabot or Codespaces secrets instead of the
+Actions secrets. Each store is kept in its own section of the backup.
 
 If key or value are not provided via flags, you will be prompted to enter them.
 The value input will be hidden for security.
 
-Note: GCP backup is not yet implemented. Please use AWS backup or none.
-
 Example:
   ghsecrets push -k API_KEY -v "secret-value" -b aws
+  ghsecrets push -k API_KEY -v "secret-value" -b gcp
   ghsecrets push -k DATABASE_URL -b aws  # Will prompt for value
+  ghsecrets push -k DEPLOY_KEY -b aws --environment production
+  ghsecrets push -k NPM_TOKEN -b aws --org my-org --visibility selected --selected-repos api,web
+  ghsecrets push -k REGISTRY_TOKEN -b aws --store dependabot
+  ghsecrets push --from-env-file .env -b aws
+  ghsecrets push --from-json secrets.json -b gcp
   ghsecrets push -k TOKEN  # Will prompt for value
   ghsecrets push  # Will prompt for both key and value`,
 	RunE: runPush,
@@ -52,38 +87,57 @@ func init() {
 
 	pushCmd.Flags().StringVarP(&key, "key", "k", "", "Secret key name (will prompt if not provided)")
 	pushCmd.Flags().StringVarP(&value, "value", "v", "", "Secret value (will prompt if not provided)")
-	pushCmd.Flags().StringVarP(&backup, "backup", "b", "", "Backup destination: aws or none (gcp not yet implemented)")
+	pushCmd.Flags().StringVarP(&backup, "backup", "b", "", "Backup destination: aws, gcp or none")
 	pushCmd.Flags().StringVarP(&owner, "owner", "o", "", "GitHub repository owner")
 	pushCmd.Flags().StringVarP(&repo, "repo", "r", "", "GitHub repository name")
+	pushCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to push the secret to")
+	pushCmd.Flags().String("org", "", "GitHub organization to push an organization secret to")
+	pushCmd.Flags().StringVar(&visibility, "visibility", "", "Organization secret visibility: all, private or selected")
+	pushCmd.Flags().StringVar(&pushStore, "store", "actions", "Secret store to push to: actions, dependabot or codespaces")
+	pushCmd.Flags().StringSliceVar(&selectedRepos, "selected-repos", nil, "Repositories (names or IDs) that can access a secret with 'selected' visibility")
+	pushCmd.Flags().StringVar(&fromEnvFile, "from-env-file", "", "Push every entry of a dotenv file")
+	pushCmd.Flags().StringVar(&fromJSON, "from-json", "", "Push every entry of a JSON object file")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "Print the planned changes without writing to the backup or GitHub")
+	pushCmd.Flags().BoolVar(&noRollback, "no-rollback", false, "Keep the new backup value when pushing to GitHub fails")
 	pushCmd.Flags().StringVar(&region, "aws-region", "us-east-1", "AWS region for Secrets Manager")
 	pushCmd.Flags().StringVar(&awsProfile, "aws-profile", "", "AWS profile to use (from ~/.aws/credentials)")
 	pushCmd.Flags().StringVar(&project, "gcp-project", "", "GCP project ID")
 
-	viper.BindPFlag("github.owner", pushCmd.Flags().Lookup("owner"))
-	viper.BindPFlag("github.repo", pushCmd.Flags().Lookup("repo"))
-	viper.BindPFlag("aws.region", pushCmd.Flags().Lookup("aws-region"))
-	viper.BindPFlag("aws.profile", pushCmd.Flags().Lookup("aws-profile"))
-	viper.BindPFlag("gcp.project", pushCmd.Flags().Lookup("gcp-project"))
+	pushCmd.MarkFlagsMutuallyExclusive("from-env-file", "from-json")
+	pushCmd.MarkFlagsMutuallyExclusive("from-env-file", "key")
+	pushCmd.MarkFlagsMutuallyExclusive("from-env-file", "value")
+	pushCmd.MarkFlagsMutuallyExclusive("from-json", "key")
+	pushCmd.MarkFlagsMutuallyExclusive("from-json", "value")
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
-	// Get GitHub token
-	ghToken, err := auth.GetGitHubToken(viper.GetString("github.token"))
+	if viper.GetString("github.org") == "" && (visibility != "" || len(selectedRepos) > 0) {
+		return fmt.Errorf("--visibility and --selected-repos can only be used with --org")
+	}
+
+	store, err := github.ParseStore(pushStore)
+	if err != nil {
+		return err
+	}
+
+	ghClient, githubOwner, githubRepo, err := newGitHubClient()
 	if err != nil {
 		return err
 	}
+	ghClient = ghClient.WithStore(store)
 
-	if owner == "" {
-		owner = viper.GetString("github.owner")
+	backend := strings.ToLower(backup)
+	if backend == "none" {
+		backend = ""
 	}
-	if repo == "" {
-		repo = viper.GetString("github.repo")
+	if _, ok := backupNames[backend]; backend != "" && !ok {
+		return fmt.Errorf("invalid backup destination: %s (use 'aws', 'gcp' or 'none')", backup)
 	}
 
-	if owner == "" || repo == "" {
-		return fmt.Errorf("GitHub owner and repo must be specified via flags or config file")
+	if fromEnvFile != "" || fromJSON != "" {
+		return runPushBatch(ctx, ghClient, store, backend, storeTargetName(store, githubOwner, githubRepo))
 	}
 
 	// If key is not provided, prompt for it
@@ -118,74 +172,316 @@ func runPush(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Handle backup first if specified
-	if backup != "" && backup != "none" {
-		fmt.Printf("Creating backup for secret '%s'...\n", key)
-		switch strings.ToLower(backup) {
-		case "aws":
-			if err := backupToAWS(ctx, key, value); err != nil {
-				return fmt.Errorf("failed to backup to AWS: %w", err)
-			}
-			fmt.Println("✓ Successfully backed up to AWS Secrets Manager")
+	// Resolve organization secret settings before anything is written
+	var orgSettings *github.OrgSecretSettings
+	if ghClient.IsOrg() {
+		orgSettings, err = resolveOrgSecretSettings(ctx, ghClient, key)
+		if err != nil {
+			return err
+		}
+	}
 
-		case "gcp":
-			return fmt.Errorf("GCP backup is not yet implemented. Please use 'aws' or 'none'")
+	if pushDryRun {
+		return planPush(ctx, ghClient, store, backend, storeTargetName(store, githubOwner, githubRepo), map[string]string{key: value})
+	}
 
-		default:
-			return fmt.Errorf("invalid backup destination: %s (use 'aws' or 'none')", backup)
+	// Handle backup first if specified
+	var rollback backupRollback
+	if backend != "" {
+		var settings map[string]string
+		if orgSettings != nil {
+			settings = map[string]string{key: orgSettings.String()}
 		}
+
+		fmt.Printf("Creating backup for secret '%s'...\n", key)
+		rollback, err = backupKeys(ctx, backend, store, map[string]string{key: value}, settings)
+		if err != nil {
+			return fmt.Errorf("failed to backup to %s: %w", strings.ToUpper(backend), err)
+		}
+		fmt.Printf("✓ Successfully backed up to %s\n", backupNames[backend])
 	}
 
 	// Push to GitHub Secrets after successful backup (or if no backup specified)
-	fmt.Printf("Pushing secret '%s' to GitHub repository %s/%s...\n", key, owner, repo)
-	ghClient := github.NewClient(ghToken, owner, repo)
-	if err := ghClient.CreateOrUpdateSecret(ctx, key, value); err != nil {
-		return fmt.Errorf("failed to push to GitHub: %w", err)
+	fmt.Printf("Pushing secret '%s' to %s...\n", key, storeTargetName(store, githubOwner, githubRepo))
+	if orgSettings != nil {
+		err = ghClient.CreateOrUpdateOrgSecret(ctx, key, value, *orgSettings)
+	} else {
+		err = ghClient.CreateOrUpdateSecret(ctx, key, value)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to push to GitHub: %w", err)
+		return rollbackBackup(ctx, backend, rollback, []string{key}, err)
 	}
 	fmt.Println("✓ Successfully pushed to GitHub Secrets")
 
 	return nil
 }
 
-func backupToAWS(ctx context.Context, key, value string) error {
-	awsRegion := viper.GetString("aws.region")
-	if awsRegion == "" {
-		awsRegion = "us-east-1"
+// resolveOrgSecretSettings builds the visibility settings for an organization secret from
+// --visibility and --selected-repos, keeping the current settings when no visibility is given
+func resolveOrgSecretSettings(ctx context.Context, ghClient *github.Client, name string) (*github.OrgSecretSettings, error) {
+	if visibility == "" {
+		if len(selectedRepos) > 0 {
+			return nil, fmt.Errorf("--selected-repos requires --visibility selected")
+		}
+		settings, err := ghClient.GetOrgSecretSettings(ctx, name)
+		if err != nil {
+			if !github.IsSecretNotFound(err) {
+				return nil, err
+			}
+			settings = github.OrgSecretSettings{Visibility: github.VisibilityPrivate}
+		}
+		return &settings, nil
 	}
 
-	awsProfile := viper.GetString("aws.profile")
-	awsSecretName := viper.GetString("aws.secret_name")
-	if awsSecretName == "" {
-		// Default secret name if not specified
-		awsSecretName = fmt.Sprintf("github-secrets-%s-%s", owner, repo)
+	settings := github.OrgSecretSettings{Visibility: strings.ToLower(visibility)}
+	if len(selectedRepos) > 0 {
+		if settings.Visibility != github.VisibilitySelected {
+			return nil, fmt.Errorf("--selected-repos requires --visibility selected")
+		}
+		ids, err := ghClient.ResolveRepositoryIDs(ctx, selectedRepos)
+		if err != nil {
+			return nil, err
+		}
+		settings.SelectedRepositoryIDs = ids
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
 	}
-	
-	awsClient, err := aws.NewClientWithOptions(aws.ClientOptions{
-		Region:  awsRegion,
-		Profile: awsProfile,
-	})
+
+	return &settings, nil
+}
+
+// runPushBatch pushes every entry of --from-env-file or --from-json, backing them up in one write first
+func runPushBatch(ctx context.Context, ghClient *github.Client, store github.Store, backend, target string) error {
+	entries, source, err := readBatchFile()
 	if err != nil {
 		return err
 	}
 
-	// Use JSON client to store multiple keys in a single secret
-	jsonClient := aws.NewJSONClient(awsClient, awsSecretName)
-	return jsonClient.AddOrUpdateKey(ctx, key, value)
+	if len(entries) == 0 {
+		fmt.Printf("No secrets found in %s\n", source)
+		return nil
+	}
+	for name := range entries {
+		if err := github.ValidateSecretName(name); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	// Resolve organization secret settings before anything is written
+	write := ghClient.CreateOrUpdateSecret
+	var settings map[string]string
+	if ghClient.IsOrg() {
+		settings = make(map[string]string, len(entries))
+		for name := range entries {
+			orgSettings, err := resolveOrgSecretSettings(ctx, ghClient, name)
+			if err != nil {
+				return err
+			}
+			settings[name] = orgSettings.String()
+		}
+		write = orgSecretWriter(ghClient, settings)
+	}
+
+	if pushDryRun {
+		return planPush(ctx, ghClient, store, backend, target, entries)
+	}
+
+	var rollback backupRollback
+	if backend != "" {
+		fmt.Printf("Creating backup for %d secrets from %s...\n", len(entries), source)
+		rollback, err = backupKeys(ctx, backend, store, entries, settings)
+		if err != nil {
+			return fmt.Errorf("failed to backup to %s: %w", strings.ToUpper(backend), err)
+		}
+		fmt.Printf("✓ Successfully backed up to %s\n", backupNames[backend])
+	}
+
+	fmt.Printf("Pushing %d secrets from %s to %s\n", len(entries), source, target)
+	return pushKeysWithRollback(ctx, backend, "secret", write, rollback, entries, 1)
 }
 
-func backupToGCP(ctx context.Context, key, value string) error {
-	gcpProject := viper.GetString("gcp.project")
-	if gcpProject == "" {
-		return fmt.Errorf("GCP project ID not specified. Use --gcp-project flag or configure in .ghsecrets.yaml")
+// pushKeysWithRollback writes the keys to GitHub like writeKeys and rolls back the backup
+// values of the keys that failed to push. The other keys were deployed and keep their values.
+func pushKeysWithRollback(ctx context.Context, backend, kind string, write secretWriter, rollback backupRollback, keys map[string]string, concurrency int) error {
+	var mu sync.Mutex
+	var failed []string
+	recordFailures := func(ctx context.Context, name, value string) error {
+		err := write(ctx, name, value)
+		if err != nil {
+			mu.Lock()
+			failed = append(failed, name)
+			mu.Unlock()
+		}
+		return err
 	}
 
-	gcpCreds := viper.GetString("gcp.credentials_path")
-	
-	gcpClient, err := gcp.NewClient(gcpProject, gcpCreds)
+	if err := writeKeys(ctx, pushOperation, kind, recordFailures, keys, concurrency); err != nil {
+		return rollbackBackup(ctx, backend, rollback, failed, err)
+	}
+	return nil
+}
+
+// rollbackBackup restores the previous backup values of the named keys after pushErr,
+// unless there is nothing to roll back or --no-rollback is set. It returns pushErr.
+func rollbackBackup(ctx context.Context, backend string, rollback backupRollback, names []string, pushErr error) error {
+	if rollback == nil || len(names) == 0 {
+		return pushErr
+	}
+	if noRollback {
+		fmt.Printf("Keeping the new backup values in %s (--no-rollback)\n", backupNames[backend])
+		return pushErr
+	}
+
+	fmt.Printf("Rolling back %d backup values in %s...\n", len(names), backupNames[backend])
+	if err := rollback(ctx, names); err != nil {
+		return fmt.Errorf("%w (rolling back the %s backup also failed: %v)", pushErr, strings.ToUpper(backend), err)
+	}
+	fmt.Println("✓ Restored the previous backup values")
+	return pushErr
+}
+
+// planPush prints what pushing the entries would change in the backup and in GitHub
+func planPush(ctx context.Context, ghClient *github.Client, store github.Store, backend, target string, entries map[string]string) error {
+	fmt.Print("Dry run: no changes will be made\n\n")
+
+	if backend != "" {
+		sections, secretName, closeStore, err := openBackupSections(backend)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		current, err := currentBackupKeys(ctx, backend, sections(storeBackupSection(store, backupSection())))
+		if err != nil {
+			return err
+		}
+		backupTarget := fmt.Sprintf("%s secret '%s'", backupNames[backend], secretName)
+		writePlan(os.Stdout, backupTarget, "secret", planKeys(entries, current, true))
+	}
+
+	current, err := githubSecretNames(ctx, ghClient)
 	if err != nil {
 		return err
 	}
-	defer gcpClient.Close()
+	writePlan(os.Stdout, target, "secret", planKeys(entries, current, false))
+	return nil
+}
+
+// readBatchFile parses the file given with --from-env-file or --from-json
+func readBatchFile() (map[string]string, string, error) {
+	path, parse := fromEnvFile, envfile.ParseDotenv
+	if fromJSON != "" {
+		path, parse = fromJSON, envfile.ParseJSON
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	entries, err := parse(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return entries, path, nil
+}
+
+// backupRollback restores the backup values that a push replaced for the named keys
+type backupRollback func(ctx context.Context, names []string) error
+
+// backupKeys stores the keys in the backup bundle of the backend with a single write.
+// Settings of organization secrets are stored next to the values so restore can reapply them.
+// The returned rollback restores the values the keys had before.
+func backupKeys(ctx context.Context, backend string, store github.Store, keys, orgSettings map[string]string) (backupRollback, error) {
+	secretName, err := backupSecretName(backend)
+	if err != nil {
+		return nil, err
+	}
+	keysSection := storeBackupSection(store, backupSection())
+	settingsSection := storeBackupSection(store, bundle.OrgSettingsSection(viper.GetString("github.org")))
+	open := func() (*backupSecret, error) { return openNamedBackupSecret(backend, secretName, "") }
+	return backupKeysTo(ctx, backend, open, keysSection, settingsSection, keys, orgSettings)
+}
+
+// backupKeysTo is like backupKeys for the given sections of the backup secret opened by open
+func backupKeysTo(ctx context.Context, backend string, open func() (*backupSecret, error), keysSection, settingsSection string, keys, orgSettings map[string]string) (backupRollback, error) {
+	secret, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer secret.close()
+	sections := secret.sections
+
+	// The previous values are kept as stored, so a rollback doesn't need to decrypt them
+	previousKeys, err := currentBackupKeys(ctx, backend, rawBackupStore(sections(keysSection)))
+	if err != nil {
+		return nil, err
+	}
+	var previousSettings map[string]string
+	if len(orgSettings) > 0 {
+		previousSettings, err = currentBackupKeys(ctx, backend, rawBackupStore(sections(settingsSection)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rollback := func(ctx context.Context, names []string) error {
+		secret, err := open()
+		if err != nil {
+			return err
+		}
+		defer secret.close()
+		sections := secret.sections
+
+		if err := restoreBackupKeys(ctx, rawBackupStore(sections(keysSection)), previousKeys, names); err != nil {
+			return err
+		}
+		if len(orgSettings) == 0 {
+			return nil
+		}
+		return restoreBackupKeys(ctx, rawBackupStore(sections(settingsSection)), previousSettings, names)
+	}
+
+	if err := sections(keysSection).AddOrUpdateKeys(ctx, keys); err != nil {
+		return nil, err
+	}
+	if len(orgSettings) == 0 {
+		return rollback, nil
+	}
 
-	return gcpClient.CreateOrUpdateSecret(ctx, key, value)
-}
\ No newline at end of file
+	if err := sections(settingsSection).AddOrUpdateKeys(ctx, orgSettings); err != nil {
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		if rollbackErr := restoreBackupKeys(ctx, rawBackupStore(sections(keysSection)), previousKeys, names); rollbackErr != nil {
+			return nil, fmt.Errorf("%w (rolling back the values also failed: %v)", err, rollbackErr)
+		}
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// restoreBackupKeys puts the previous values of the named keys back into a backup section,
+// removing the keys that didn't exist before
+func restoreBackupKeys(ctx context.Context, store backupStore, previous map[string]string, names []string) error {
+	restored := make(map[string]string)
+	for _, name := range names {
+		value, existed := previous[name]
+		if existed {
+			restored[name] = value
+			continue
+		}
+		if err := store.RemoveKey(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	if len(restored) == 0 {
+		return nil
+	}
+	return store.AddOrUpdateKeys(ctx, restored)
+}
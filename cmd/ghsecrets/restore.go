@@ -3,23 +3,83 @@ package ghsecrets
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/tom-023/ghsecrets/internal/auth"
-	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 	"github.com/tom-023/ghsecrets/internal/github"
 )
 
 var (
-	restoreBackup string
+	restoreBackup  string
+	restoreStore   string
+	restoreDryRun  bool
+	restoreVersion string
+	restoreAt      string
+	restoreKeys    []string
+	restoreInclude []string
+	restoreExclude []string
+	restoreMatch   string
+
+	restoreConcurrency int
+
+	restoreFromSecretName string
+	restoreSourceRepo     string
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "Restore GitHub Secrets from backup",
-	Long:  `Restore GitHub Secrets from AWS Secrets Manager or GCP Secret Manager`,
-	RunE:  runRestore,
+	Long: `Restore GitHub Secrets from AWS Secrets Manager or GCP Secret Manager.
+
+Use --environment to restore the secrets of an Actions environment, which are
+kept in their own section of the backup.
+
+Use --org to restore organization secrets. The visibility and selected
+repositories recorded at push time are reapplied.
+
+Actions variables backed up with 'ghsecrets vars push' are restored as well.
+
+Use --dry-run to print which secrets and variables would be created or updated
+without writing anything to GitHub.
+
+Use --store to restore the Dependabot or Codespaces secrets instead of the
+Actions secrets.
+
+Use --version (see 'ghsecrets history') or --at to restore the secrets from an
+earlier version of the backup, for example to undo a bad push. Keys added since
+then are left in place, and the backup itself is not changed.
+
+Use --key, --include, --exclude and --match to restore only some of the keys,
+for example a single corrupted secret or a prefix group. A key is restored when
+it is named with --key, matches an --include glob or the --match regular
+expression, and doesn't match an --exclude glob. Names, globs and the regular
+expression are compared case-insensitively, and the filters apply to secrets and
+variables alike.
+
+Use --source-repo or --from-secret-name to read the backup of another repository,
+for example to restore the secrets of a template into a new repository. The
+destination is still selected with --owner and --repo.
+
+Keys are restored by --concurrency parallel workers. Requests that hit GitHub's
+rate limits wait as long as GitHub asks and are retried, and requests that fail
+with a server error are retried with exponential backoff.
+
+Example:
+  ghsecrets restore -b aws
+  ghsecrets restore -b gcp --gcp-project my-project
+  ghsecrets restore -b aws --environment production
+  ghsecrets restore -b aws --org my-org
+  ghsecrets restore -b aws --store dependabot
+  ghsecrets restore -b aws --at 2024-05-01T09:00:00Z
+  ghsecrets restore -b aws --key API_KEY
+  ghsecrets restore -b aws --include 'STRIPE_*' --exclude STRIPE_WEBHOOK_SECRET
+  ghsecrets restore -b aws --source-repo my-org/service-template --owner my-org --repo new-service`,
+	RunE: runRestore,
 }
 
 func init() {
@@ -31,6 +91,21 @@ func init() {
 	// Repository flags
 	restoreCmd.Flags().String("owner", "", "GitHub repository owner")
 	restoreCmd.Flags().String("repo", "", "GitHub repository name")
+	restoreCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment to restore secrets to")
+	restoreCmd.Flags().String("org", "", "GitHub organization to restore organization secrets to")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Print the planned changes without writing to GitHub")
+	restoreCmd.Flags().StringVar(&restoreStore, "store", "actions", "Secret store to restore: actions, dependabot or codespaces")
+	restoreCmd.Flags().StringVar(&restoreVersion, "version", "", "Backup version to restore from (see 'ghsecrets history')")
+	restoreCmd.Flags().StringVar(&restoreAt, "at", "", "Restore the backup as it was at this time (RFC3339)")
+	restoreCmd.MarkFlagsMutuallyExclusive("version", "at")
+	restoreCmd.Flags().StringSliceVarP(&restoreKeys, "key", "k", nil, "Only restore these keys")
+	restoreCmd.Flags().StringSliceVar(&restoreInclude, "include", nil, "Only restore keys matching these glob patterns")
+	restoreCmd.Flags().StringSliceVar(&restoreExclude, "exclude", nil, "Skip keys matching these glob patterns")
+	restoreCmd.Flags().StringVar(&restoreMatch, "match", "", "Only restore keys matching this regular expression")
+	restoreCmd.Flags().IntVar(&restoreConcurrency, "concurrency", 4, "Number of keys to restore in parallel")
+	restoreCmd.Flags().StringVar(&restoreFromSecretName, "from-secret-name", "", "Backup secret to restore from instead of the configured one")
+	restoreCmd.Flags().StringVar(&restoreSourceRepo, "source-repo", "", "Restore from the backup of this repository (owner/repo)")
+	restoreCmd.MarkFlagsMutuallyExclusive("from-secret-name", "source-repo")
 
 	// AWS specific flags
 	restoreCmd.Flags().String("aws-region", "us-east-1", "AWS region")
@@ -38,13 +113,6 @@ func init() {
 
 	// GCP specific flags
 	restoreCmd.Flags().String("gcp-project", "", "GCP project ID")
-
-	// Bind flags to viper
-	viper.BindPFlag("github.owner", restoreCmd.Flags().Lookup("owner"))
-	viper.BindPFlag("github.repo", restoreCmd.Flags().Lookup("repo"))
-	viper.BindPFlag("aws.region", restoreCmd.Flags().Lookup("aws-region"))
-	viper.BindPFlag("aws.profile", restoreCmd.Flags().Lookup("aws-profile"))
-	viper.BindPFlag("gcp.project", restoreCmd.Flags().Lookup("gcp-project"))
 }
 
 func runRestore(cmd *cobra.Command, args []string) error {
@@ -57,87 +125,277 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	case "aws":
 		return runRestoreAWS(cmd, args)
 	case "gcp":
-		return fmt.Errorf("GCP restore is not yet implemented")
+		return runRestoreGCP(cmd, args)
 	default:
 		return fmt.Errorf("invalid backup source: %s (must be aws or gcp)", restoreBackup)
 	}
 }
 
 func runRestoreAWS(cmd *cobra.Command, args []string) error {
+	return restoreFromBackup("aws")
+}
+
+func runRestoreGCP(cmd *cobra.Command, args []string) error {
+	return restoreFromBackup("gcp")
+}
+
+// restoreFromBackup replays every key of the configured backup into GitHub
+func restoreFromBackup(backend string) error {
 	ctx := context.Background()
 
-	// Get AWS configuration
-	awsRegion := viper.GetString("aws.region")
-	awsProfile := viper.GetString("aws.profile")
-	awsSecretName := viper.GetString("aws.secret_name")
+	store, err := github.ParseStore(restoreStore)
+	if err != nil {
+		return err
+	}
+	if restoreConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	filter, err := newKeyFilter(restoreKeys, restoreInclude, restoreExclude, restoreMatch)
+	if err != nil {
+		return err
+	}
 
-	if awsSecretName == "" {
-		return fmt.Errorf("AWS secret name must be configured in ghsecrets.yaml")
+	// Create GitHub client
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
 	}
+	githubClient = githubClient.WithStore(store)
 
-	// Get GitHub configuration
-	githubOwner := viper.GetString("github.owner")
-	githubRepo := viper.GetString("github.repo")
+	secretName, err := sourceBackupSecretName(backend, restoreFromSecretName, restoreSourceRepo)
+	if err != nil {
+		return err
+	}
 
-	if githubOwner == "" || githubRepo == "" {
-		return fmt.Errorf("GitHub owner and repo must be specified")
+	versionID, err := resolveBackupVersion(ctx, backend, secretName, restoreVersion, restoreAt)
+	if err != nil {
+		return err
 	}
 
-	// Get GitHub token using the same auth logic as push command
-	githubToken, err := auth.GetGitHubToken(viper.GetString("github.token"))
+	// Create backup JSON client
+	secret, err := openNamedBackupSecret(backend, secretName, versionID)
 	if err != nil {
 		return err
 	}
+	defer secret.close()
+	sections := secret.sections
 
-	// Create AWS client
-	awsOpts := aws.ClientOptions{
-		Region:  awsRegion,
-		Profile: awsProfile,
+	if restoreFromSecretName != "" || restoreSourceRepo != "" {
+		fmt.Printf("Restoring from %s secret '%s'\n", backupNames[backend], secret.name)
 	}
-	awsClient, err := aws.NewClientWithOptions(awsOpts)
+	if versionID != "" {
+		fmt.Printf("Restoring from version %s of %s secret '%s'\n", versionID, backupNames[backend], secret.name)
+	}
+
+	keys, variables, err := readRestoreKeys(ctx, backend, sections, store, !githubClient.IsOrg())
 	if err != nil {
-		return fmt.Errorf("failed to create AWS client: %w", err)
+		return err
 	}
 
-	// Create GitHub client
-	githubClient := github.NewClient(githubToken, githubOwner, githubRepo)
+	if len(keys) == 0 && len(variables) == 0 {
+		fmt.Printf("No secrets found in %s\n", backupNames[backend])
+		return nil
+	}
 
-	// Create AWS JSON client
-	jsonClient := aws.NewJSONClient(awsClient, awsSecretName)
+	if !filter.empty() {
+		if missing := filter.missing(keys, variables); len(missing) > 0 {
+			return fmt.Errorf("keys not found in %s: %s", backupNames[backend], strings.Join(missing, ", "))
+		}
+
+		total := len(keys) + len(variables)
+		keys = filter.apply(keys)
+		variables = filter.apply(variables)
+		if len(keys) == 0 && len(variables) == 0 {
+			fmt.Printf("No secrets in %s match the given filters\n", backupNames[backend])
+			return nil
+		}
+		fmt.Printf("Selected %d of %d keys in %s\n", len(keys)+len(variables), total, backupNames[backend])
+	}
+
+	if restoreDryRun {
+		return planRestore(ctx, githubClient, storeTargetName(store, githubOwner, githubRepo), githubTargetName(githubOwner, githubRepo), keys, variables)
+	}
+
+	var restoreErr error
+	if len(keys) > 0 {
+		write := githubClient.CreateOrUpdateSecret
+		if githubClient.IsOrg() {
+			settingsSection := storeBackupSection(store, bundle.OrgSettingsSection(viper.GetString("github.org")))
+			settings, err := sections(settingsSection).GetAllKeys(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve secret settings from %s: %w", backupNames[backend], err)
+			}
+			write = orgSecretWriter(githubClient, settings)
+		}
+
+		fmt.Printf("Restoring %d secrets from %s to %s\n", len(keys), backupNames[backend], storeTargetName(store, githubOwner, githubRepo))
+		restoreErr = restoreSecrets(ctx, write, keys, restoreConcurrency)
+	}
+
+	if len(variables) > 0 {
+		fmt.Printf("Restoring %d variables from %s to %s\n", len(variables), backupNames[backend], githubTargetName(githubOwner, githubRepo))
+		if err := restoreVariables(ctx, githubClient.CreateOrUpdateVariable, variables, restoreConcurrency); err != nil && restoreErr == nil {
+			restoreErr = err
+		}
+	}
 
-	// Get all keys from AWS
-	keys, err := jsonClient.GetAllKeys(ctx)
+	return restoreErr
+}
+
+// readRestoreKeys reads the secrets of the store from the backup of the configured target.
+// Actions variables of a repository or environment are restored along with its secrets,
+// so they are read as well when withVariables is set.
+func readRestoreKeys(ctx context.Context, backend string, sections func(section string) backupStore, store github.Store, withVariables bool) (map[string]string, map[string]string, error) {
+	keys, err := sections(storeBackupSection(store, backupSection())).GetAllKeys(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve secrets from AWS: %w", err)
+		return nil, nil, fmt.Errorf("failed to retrieve secrets from %s: %w", backupNames[backend], err)
 	}
 
-	if len(keys) == 0 {
-		fmt.Println("No secrets found in AWS Secrets Manager")
-		return nil
+	var variables map[string]string
+	if store == github.StoreActions && withVariables {
+		variables, err = sections(variablesBackupSection()).GetAllKeys(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve variables from %s: %w", backupNames[backend], err)
+		}
 	}
+	return keys, variables, nil
+}
 
-	// Restore each secret to GitHub
-	fmt.Printf("Restoring %d secrets from AWS to GitHub repository %s/%s\n", len(keys), githubOwner, githubRepo)
-	
-	successCount := 0
-	for key, value := range keys {
-		fmt.Printf("Restoring secret: %s... ", key)
-		
-		err := githubClient.CreateOrUpdateSecret(ctx, key, value)
+// planRestore prints what restoring the secrets and variables would change in GitHub
+func planRestore(ctx context.Context, githubClient *github.Client, secretsTarget, variablesTarget string, keys, variables map[string]string) error {
+	fmt.Print("Dry run: no changes will be made\n\n")
+
+	if len(keys) > 0 {
+		current, err := githubSecretNames(ctx, githubClient)
+		if err != nil {
+			return err
+		}
+		writePlan(os.Stdout, secretsTarget, "secret", planKeys(keys, current, false))
+	}
+
+	if len(variables) > 0 {
+		current, err := githubVariableValues(ctx, githubClient)
+		if err != nil {
+			return err
+		}
+		writePlan(os.Stdout, variablesTarget, "variable", planKeys(variables, current, true))
+	}
+
+	return nil
+}
+
+// secretWriter creates or updates a single secret in GitHub
+type secretWriter func(ctx context.Context, name, value string) error
+
+// orgSecretWriter restores organization secrets with the settings recorded in the backup.
+// Secrets without recorded settings keep their current visibility in GitHub.
+func orgSecretWriter(githubClient *github.Client, settings map[string]string) secretWriter {
+	return func(ctx context.Context, name, value string) error {
+		recorded, ok := settings[name]
+		if !ok {
+			return githubClient.CreateOrUpdateSecret(ctx, name, value)
+		}
+
+		orgSettings, err := github.ParseOrgSecretSettings(recorded)
 		if err != nil {
-			fmt.Printf("FAILED: %v\n", err)
-			continue
+			return fmt.Errorf("invalid settings in backup: %w", err)
 		}
-		
-		fmt.Println("OK")
-		successCount++
+		return githubClient.CreateOrUpdateOrgSecret(ctx, name, value, orgSettings)
 	}
+}
+
+// restoreSecrets writes each secret to GitHub and reports per-key results
+func restoreSecrets(ctx context.Context, write secretWriter, keys map[string]string, concurrency int) error {
+	return writeKeys(ctx, restoreOperation, "secret", write, keys, concurrency)
+}
+
+// restoreVariables writes each variable to GitHub and reports per-key results
+func restoreVariables(ctx context.Context, write secretWriter, variables map[string]string, concurrency int) error {
+	return writeKeys(ctx, restoreOperation, "variable", write, variables, concurrency)
+}
+
+// keyOperation names an operation in the per-key output of writeKeys
+type keyOperation struct {
+	progress string
+	summary  string
+	done     string
+}
+
+var (
+	restoreOperation = keyOperation{progress: "Restoring", summary: "Restore", done: "restored"}
+	pushOperation    = keyOperation{progress: "Pushing", summary: "Push", done: "pushed"}
+)
+
+// writeKeys writes each key in name order, reporting every result, and fails if any key failed.
+// With a concurrency above 1 the keys are written by that many workers and each result
+// is reported once its write completes.
+func writeKeys(ctx context.Context, op keyOperation, kind string, write secretWriter, keys map[string]string, concurrency int) error {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	successCount := 0
+	if concurrency <= 1 {
+		for _, key := range names {
+			fmt.Printf("%s %s: %s... ", op.progress, kind, key)
+
+			err := write(ctx, key, keys[key])
+			if err != nil {
+				fmt.Printf("FAILED: %v\n", err)
+				continue
+			}
+
+			fmt.Println("OK")
+			successCount++
+		}
+	} else {
+		successCount = writeKeysConcurrently(ctx, op, kind, write, keys, names, concurrency)
+	}
+
+	fmt.Printf("\n%s complete: %d/%d %ss successfully %s\n", op.summary, successCount, len(keys), kind, op.done)
 
-	fmt.Printf("\nRestore complete: %d/%d secrets successfully restored\n", successCount, len(keys))
-	
 	if successCount < len(keys) {
-		return fmt.Errorf("some secrets failed to restore")
+		return fmt.Errorf("some %ss failed to %s", kind, strings.ToLower(op.summary))
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// writeKeysConcurrently writes the named keys with a pool of workers, printing each
+// result on a line of its own, and returns the number of keys written
+func writeKeysConcurrently(ctx context.Context, op keyOperation, kind string, write secretWriter, keys map[string]string, names []string, concurrency int) int {
+	work := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	successCount := 0
+
+	for i := 0; i < concurrency && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				err := write(ctx, key, keys[key])
+
+				mu.Lock()
+				if err != nil {
+					fmt.Printf("%s %s: %s... FAILED: %v\n", op.progress, kind, key, err)
+				} else {
+					fmt.Printf("%s %s: %s... OK\n", op.progress, kind, key)
+					successCount++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range names {
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+
+	return successCount
+}
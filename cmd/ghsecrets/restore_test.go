@@ -3,12 +3,17 @@ package ghsecrets
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tom-023/ghsecrets/internal/aws"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/gcp"
+	"github.com/tom-023/ghsecrets/internal/github"
 )
 
 // MockAWSClient is a test mock for AWS operations
@@ -39,6 +44,13 @@ func (m *MockAWSClient) GetSecret(ctx context.Context, name string) (string, err
 	return value, nil
 }
 
+func (m *MockAWSClient) GetLastChangedDate(ctx context.Context, name string) (time.Time, error) {
+	if m.err != nil {
+		return time.Time{}, m.err
+	}
+	return time.Time{}, nil
+}
+
 // MockGitHubClient is a test mock for GitHub operations
 type MockGitHubClient struct {
 	secrets       map[string]string
@@ -253,6 +265,56 @@ func TestRestoreAWSPartialFailure(t *testing.T) {
 	assert.Len(t, mockGitHub.secrets, 2)
 }
 
+func TestRestoreGCP(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	viper.Reset()
+	viper.Set("gcp.project", "test-project")
+	viper.Set("github.owner", "owner")
+	viper.Set("github.repo", "repo")
+
+	// GCPモックにセクション付きのバックアップを作成
+	mockGCP := useMockGCP(t)
+	require.NoError(t, mockGCP.CreateOrUpdateSecret(ctx, "test-secret", "{}"))
+	backup := gcp.NewJSONClient(mockGCP, "test-secret")
+	require.NoError(t, backup.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "secret-api-key", "TOKEN": "bearer-token"}))
+	require.NoError(t, backup.Section(bundle.VariablesSection(bundle.RootSection)).AddOrUpdateKeys(ctx, map[string]string{"REGION": "eu-west-1"}))
+	require.NoError(t, backup.Section(bundle.EnvironmentSection("production")).AddOrUpdateKeys(ctx, map[string]string{"PROD_ONLY": "p"}))
+
+	// restoreと同じ経路でバックアップを読み、GitHubモックに書き込む
+	secret, err := openNamedBackupSecret("gcp", "test-secret", "")
+	require.NoError(t, err)
+	defer secret.close()
+
+	keys, variables, err := readRestoreKeys(ctx, "gcp", secret.sections, github.StoreActions, true)
+	require.NoError(t, err)
+
+	mockGitHub := github.NewMockClient("test-token", "owner", "repo")
+	require.NoError(t, restoreSecrets(ctx, mockGitHub.CreateOrUpdateSecret, keys, 2))
+	require.NoError(t, restoreVariables(ctx, mockGitHub.CreateOrUpdateVariable, variables, 2))
+
+	// GitHubが受け取ったのはリポジトリのセクションのキーだけ
+	secrets, err := mockGitHub.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "API_KEY", secrets[0].Name)
+	assert.Equal(t, "TOKEN", secrets[1].Name)
+
+	value, err := mockGitHub.GetSecret(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-api-key", value)
+	value, err = mockGitHub.GetSecret(ctx, "TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "bearer-token", value)
+
+	githubVariables, err := mockGitHub.ListVariables(ctx)
+	require.NoError(t, err)
+	require.Len(t, githubVariables, 1)
+	assert.Equal(t, "REGION", githubVariables[0].Name)
+	assert.Equal(t, "eu-west-1", githubVariables[0].Value)
+}
+
 func TestRestoreAWSConfigValidation(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -326,3 +388,59 @@ func TestRestoreAWSConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoreSecretsWithWriter(t *testing.T) {
+	// 書き込み関数を差し替えて restoreSecrets を検証する
+	ctx := context.Background()
+
+	mockGitHub := NewMockGitHubClient()
+	keys := map[string]string{"SECRET1": "value1", "SECRET2": "value2"}
+
+	err := restoreSecrets(ctx, mockGitHub.CreateOrUpdateSecret, keys, 1)
+	require.NoError(t, err)
+	assert.Equal(t, keys, mockGitHub.secrets)
+
+	// 失敗したシークレットがあればエラーを返す
+	failing := NewMockGitHubClient()
+	failing.failOnNthCall = 1
+	err = restoreSecrets(ctx, failing.CreateOrUpdateSecret, keys, 1)
+	assert.EqualError(t, err, "some secrets failed to restore")
+}
+
+func TestRestoreSecretsConcurrently(t *testing.T) {
+	ctx := context.Background()
+
+	keys := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		keys[fmt.Sprintf("SECRET_%02d", i)] = fmt.Sprintf("value%d", i)
+	}
+
+	var mu sync.Mutex
+	written := make(map[string]string)
+	inFlight, maxInFlight := 0, 0
+	write := func(ctx context.Context, name, value string) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight--
+		if name == "SECRET_07" {
+			return fmt.Errorf("failed to create secret: %s", name)
+		}
+		written[name] = value
+		return nil
+	}
+
+	err := restoreSecrets(ctx, write, keys, 4)
+	assert.EqualError(t, err, "some secrets failed to restore")
+	assert.Len(t, written, 19)
+	assert.LessOrEqual(t, maxInFlight, 4)
+	assert.Greater(t, maxInFlight, 1)
+}
@@ -0,0 +1,272 @@
+package ghsecrets
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/github"
+	"golang.org/x/term"
+)
+
+var (
+	rollbackKey        string
+	rollbackSource     string
+	rollbackRevision   int
+	rollbackShowValues bool
+	rollbackYes        bool
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Roll a single secret back to an earlier value from the backup history",
+	Long: `Walk the versions of the backup secret, list the earlier distinct values of a
+key with the time each was pushed, and push the chosen value to the backup and
+GitHub again.
+
+Revision 0 is the newest value, 1 the value before it, and so on. Revision 0 can
+only be picked when the current backup no longer has the key. Versions that can't
+be read are skipped with a warning. Without --version the revisions are listed
+and you are asked to pick one. Values are masked unless --show-values is given.
+
+Example:
+  ghsecrets rollback -k API_KEY -b aws
+  ghsecrets rollback -k API_KEY -b aws --version 1 --yes
+  ghsecrets rollback -k DEPLOY_KEY -b gcp --environment production`,
+	RunE: runRollback,
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().StringVarP(&rollbackKey, "key", "k", "", "Secret key name to roll back (required)")
+	rollbackCmd.Flags().StringVarP(&rollbackSource, "backup", "b", "", "Backup to read the history from (aws, gcp)")
+	rollbackCmd.Flags().IntVar(&rollbackRevision, "version", 0, "Revision to roll back to, as listed (1 is the previous value)")
+	rollbackCmd.Flags().BoolVar(&rollbackShowValues, "show-values", false, "Show the values of the revisions instead of masking them")
+	rollbackCmd.Flags().BoolVarP(&rollbackYes, "yes", "y", false, "Skip the confirmation prompt")
+
+	rollbackCmd.Flags().String("owner", "", "GitHub repository owner")
+	rollbackCmd.Flags().String("repo", "", "GitHub repository name")
+	rollbackCmd.Flags().StringP("environment", "e", "", "GitHub Actions environment of the secret")
+	rollbackCmd.Flags().String("org", "", "GitHub organization of the organization secret")
+	rollbackCmd.Flags().String("aws-region", "us-east-1", "AWS region")
+	rollbackCmd.Flags().String("aws-profile", "", "AWS profile name")
+	rollbackCmd.Flags().String("gcp-project", "", "GCP project ID")
+
+	rollbackCmd.MarkFlagRequired("key")
+}
+
+// keyRevision is a distinct value a key had in the backup history
+type keyRevision struct {
+	Number int
+	// VersionID and Changed identify the first backup version holding the value
+	VersionID string
+	Changed   time.Time
+	Value     string
+	Current   bool
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	if rollbackSource == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+	if _, ok := backupNames[rollbackSource]; !ok {
+		return fmt.Errorf("invalid backup source: %s (must be aws or gcp)", rollbackSource)
+	}
+
+	ctx := context.Background()
+
+	secret, err := openBackupSecret(rollbackSource, "")
+	if err != nil {
+		return err
+	}
+	defer secret.close()
+
+	versions, err := secret.versions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list versions in %s: %w", backupNames[rollbackSource], err)
+	}
+
+	section := backupSection()
+	revisions, err := keyRevisions(ctx, versions, func(ctx context.Context, versionID string) (map[string]string, error) {
+		return secret.sectionAt(versionID, section).GetAllKeys(ctx)
+	}, rollbackKey)
+	if err != nil {
+		return err
+	}
+
+	target := fmt.Sprintf("%s secret '%s'", backupNames[rollbackSource], secret.name)
+	if len(revisions) == 0 {
+		return fmt.Errorf("key %s not found in any version of %s", rollbackKey, target)
+	}
+	if err := writeRevisionsTable(os.Stdout, rollbackKey, target, revisions, rollbackShowValues); err != nil {
+		return err
+	}
+
+	number := rollbackRevision
+	confirmed := rollbackYes
+	if !cmd.Flags().Changed("version") {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Println("\nUse --version N to roll back to revision N")
+			return nil
+		}
+		answer, err := prompt(bufio.NewReader(os.Stdin), "\nRevision to roll back to (empty to cancel): ")
+		if err != nil {
+			return fmt.Errorf("failed to read revision: %w", err)
+		}
+		if answer == "" {
+			fmt.Println("Aborted")
+			return nil
+		}
+		if number, err = strconv.Atoi(answer); err != nil {
+			return fmt.Errorf("invalid revision: %s", answer)
+		}
+		// Picking a revision interactively is the confirmation
+		confirmed = true
+	}
+
+	revision, err := selectRevision(revisions, number)
+	if err != nil {
+		return err
+	}
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+	githubTarget := githubTargetName(githubOwner, githubRepo)
+
+	if !confirmed {
+		message := fmt.Sprintf("Roll back secret '%s' to revision %d (pushed %s) in %s and %s? [y/N]: ",
+			rollbackKey, revision.Number, revision.Changed.Format(time.RFC3339), target, githubTarget)
+		ok, err := confirm(os.Stdin, message)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	return pushRevision(ctx, githubClient, githubTarget, revision)
+}
+
+// pushRevision writes the value of a revision to the backup and then to GitHub,
+// restoring the backup if GitHub rejects it
+func pushRevision(ctx context.Context, githubClient *github.Client, githubTarget string, revision keyRevision) error {
+	var orgSettings *github.OrgSecretSettings
+	var settings map[string]string
+	if githubClient.IsOrg() {
+		var err error
+		orgSettings, err = resolveOrgSecretSettings(ctx, githubClient, rollbackKey)
+		if err != nil {
+			return err
+		}
+		settings = map[string]string{rollbackKey: orgSettings.String()}
+	}
+
+	fmt.Printf("Rolling back secret '%s' in backup...\n", rollbackKey)
+	rollback, err := backupKeys(ctx, rollbackSource, github.StoreActions, map[string]string{rollbackKey: revision.Value}, settings)
+	if err != nil {
+		return fmt.Errorf("failed to backup to %s: %w", strings.ToUpper(rollbackSource), err)
+	}
+	fmt.Printf("✓ Successfully backed up to %s\n", backupNames[rollbackSource])
+
+	fmt.Printf("Pushing secret '%s' to %s...\n", rollbackKey, githubTarget)
+	if orgSettings != nil {
+		err = githubClient.CreateOrUpdateOrgSecret(ctx, rollbackKey, revision.Value, *orgSettings)
+	} else {
+		err = githubClient.CreateOrUpdateSecret(ctx, rollbackKey, revision.Value)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to push to GitHub: %w", err)
+		return rollbackBackup(ctx, rollbackSource, rollback, []string{rollbackKey}, err)
+	}
+	fmt.Printf("✓ Rolled back secret '%s' to revision %d\n", rollbackKey, revision.Number)
+
+	return nil
+}
+
+// keyRevisions reads the key from every backup version and returns its distinct
+// values, newest first and numbered from 0. Versions without the key are skipped, and
+// so are versions that can't be read, with a warning, so one damaged version doesn't
+// hide the rest of the history.
+func keyRevisions(ctx context.Context, versions []bundle.Version, read func(ctx context.Context, versionID string) (map[string]string, error), key string) ([]keyRevision, error) {
+	ordered := make([]bundle.Version, len(versions))
+	copy(ordered, versions)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Created.Before(ordered[j].Created) })
+
+	var revisions []keyRevision
+	for _, version := range ordered {
+		keys, err := read(ctx, version.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "Warning: skipping version %s, which can't be read: %v\n", version.ID, err)
+			continue
+		}
+
+		value, exists := keys[key]
+		if !exists {
+			continue
+		}
+		if len(revisions) == 0 || revisions[len(revisions)-1].Value != value {
+			revisions = append(revisions, keyRevision{VersionID: version.ID, Changed: version.Created, Value: value})
+		}
+		if version.Current {
+			revisions[len(revisions)-1].Current = true
+		}
+	}
+
+	// Newest first
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	for i := range revisions {
+		revisions[i].Number = i
+	}
+	return revisions, nil
+}
+
+// selectRevision returns the revision with the given number, refusing the current value
+func selectRevision(revisions []keyRevision, number int) (keyRevision, error) {
+	if number < 0 || number >= len(revisions) {
+		return keyRevision{}, fmt.Errorf("revision %d not found (must be between 0 and %d)", number, len(revisions)-1)
+	}
+	if revisions[number].Current {
+		return keyRevision{}, fmt.Errorf("revision %d is already the current value of %s", number, rollbackKey)
+	}
+	return revisions[number], nil
+}
+
+// writeRevisionsTable prints the revisions of a key, masking the values unless showValues is set
+func writeRevisionsTable(w io.Writer, key, target string, revisions []keyRevision, showValues bool) error {
+	fmt.Fprintf(w, "History of %s in %s (%d revisions)\n\n", key, target, len(revisions))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tPUSHED\tVERSION\tLENGTH\tVALUE\tCURRENT")
+	for _, revision := range revisions {
+		value := maskedValue
+		if showValues {
+			value = revision.Value
+		}
+		current := ""
+		if revision.Current {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n", revision.Number, revision.Changed.Format(time.RFC3339), revision.VersionID, utf8.RuneCountInString(revision.Value), value, current)
+	}
+	return tw.Flush()
+}
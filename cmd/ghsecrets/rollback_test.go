@@ -0,0 +1,105 @@
+package ghsecrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+)
+
+func TestKeyRevisions(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 9, 0, 0, 0, time.UTC) }
+	versions := []bundle.Version{
+		{ID: "5", Created: day(5), Current: true},
+		{ID: "4", Created: day(4)},
+		{ID: "3", Created: day(3)},
+		{ID: "2", Created: day(2)},
+		{ID: "1", Created: day(1)},
+	}
+	contents := map[string]map[string]string{
+		"1": {},
+		"2": {"API_KEY": "first"},
+		"3": {"API_KEY": "first", "OTHER": "x"},
+		"4": {"API_KEY": "second"},
+		"5": {"API_KEY": "third"},
+	}
+	read := func(ctx context.Context, versionID string) (map[string]string, error) {
+		return contents[versionID], nil
+	}
+
+	revisions, err := keyRevisions(context.Background(), versions, read, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, []keyRevision{
+		{Number: 0, VersionID: "5", Changed: day(5), Value: "third", Current: true},
+		{Number: 1, VersionID: "4", Changed: day(4), Value: "second"},
+		{Number: 2, VersionID: "2", Changed: day(2), Value: "first"},
+	}, revisions)
+
+	revision, err := selectRevision(revisions, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "first", revision.Value)
+
+	defer func() { rollbackKey = "" }()
+	rollbackKey = "API_KEY"
+	_, err = selectRevision(revisions, 0)
+	assert.EqualError(t, err, "revision 0 is already the current value of API_KEY")
+	_, err = selectRevision(revisions, 3)
+	assert.EqualError(t, err, "revision 3 not found (must be between 0 and 2)")
+}
+
+func TestKeyRevisionsSkipsUnreadableVersions(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 9, 0, 0, 0, time.UTC) }
+	versions := []bundle.Version{
+		{ID: "3", Created: day(3), Current: true},
+		{ID: "2", Created: day(2)},
+		{ID: "1", Created: day(1)},
+	}
+	read := func(ctx context.Context, versionID string) (map[string]string, error) {
+		if versionID == "2" {
+			return nil, errors.New("failed to decrypt")
+		}
+		return map[string]string{"API_KEY": "value-" + versionID}, nil
+	}
+
+	revisions, err := keyRevisions(context.Background(), versions, read, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, []keyRevision{
+		{Number: 0, VersionID: "3", Changed: day(3), Value: "value-3", Current: true},
+		{Number: 1, VersionID: "1", Changed: day(1), Value: "value-1"},
+	}, revisions)
+}
+
+func TestSelectRevisionZero(t *testing.T) {
+	// The newest revision is not current when the current backup no longer has the key
+	revisions := []keyRevision{
+		{Number: 0, VersionID: "2", Value: "second"},
+		{Number: 1, VersionID: "1", Value: "first"},
+	}
+	revision, err := selectRevision(revisions, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "second", revision.Value)
+
+	_, err = selectRevision(revisions, -1)
+	assert.EqualError(t, err, "revision -1 not found (must be between 0 and 1)")
+}
+
+func TestWriteRevisionsTable(t *testing.T) {
+	revisions := []keyRevision{
+		{Number: 0, VersionID: "2", Changed: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC), Value: "new-value", Current: true},
+		{Number: 1, VersionID: "1", Changed: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), Value: "old"},
+	}
+
+	var buf strings.Builder
+	require.NoError(t, writeRevisionsTable(&buf, "API_KEY", "GCP Secret Manager secret 'backup'", revisions, false))
+	assert.Equal(t, `History of API_KEY in GCP Secret Manager secret 'backup' (2 revisions)
+
+REVISION  PUSHED                VERSION  LENGTH  VALUE     CURRENT
+0         2024-05-02T09:00:00Z  2        9       ********  *
+1         2024-05-01T09:00:00Z  1        3       ********  
+`, buf.String())
+}
@@ -30,6 +30,37 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./ghsecrets.yaml)")
+
+	// Flags are bound to viper only for the command being executed, so that
+	// commands sharing a flag name don't overwrite each other's bindings
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return bindConfigFlags(cmd)
+	}
+}
+
+// configFlags maps command flags to the config keys they override
+var configFlags = map[string]string{
+	"owner":       "github.owner",
+	"repo":        "github.repo",
+	"environment": "github.environment",
+	"org":         "github.org",
+	"aws-region":  "aws.region",
+	"aws-profile": "aws.profile",
+	"gcp-project": "gcp.project",
+}
+
+// bindConfigFlags binds the flags defined on cmd to their config keys
+func bindConfigFlags(cmd *cobra.Command) error {
+	for flagName, configKey := range configFlags {
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil {
+			continue
+		}
+		if err := viper.BindPFlag(configKey, flag); err != nil {
+			return fmt.Errorf("failed to bind flag --%s: %w", flagName, err)
+		}
+	}
+	return nil
 }
 
 func initConfig() {
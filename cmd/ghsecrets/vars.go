@@ -0,0 +1,349 @@
+package ghsecrets
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+var (
+	varsKey    string
+	varsValue  string
+	varsBackup string
+	varsFormat string
+	varsYes    bool
+)
+
+var varsCmd = &cobra.Command{
+	Use:   "vars",
+	Short: "Manage GitHub Actions configuration variables",
+	Long: `Manage the Actions configuration variables of a GitHub repository or environment.
+
+Variables are not secret: their values are readable in GitHub and shown in the
+output. In the backup they are kept in their own section, next to the secrets
+of the same repository or environment, so 'restore' brings them back too.`,
+}
+
+var varsPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Create or update a variable in GitHub and optionally backup to cloud",
+	Long: `Create or update an Actions variable in GitHub and optionally back it up to
+AWS Secrets Manager or GCP Secret Manager.
+
+If key or value are not provided via flags, you will be prompted to enter them.
+
+Example:
+  ghsecrets vars push -k LOG_LEVEL -v debug -b aws
+  ghsecrets vars push -k REGION -v eu-west-1 -b gcp --environment production`,
+	RunE: runVarsPush,
+}
+
+var varsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List variables from GitHub or from the backup",
+	Long: `List the Actions variables of the GitHub repository or environment with their values.
+With -b the variables stored in the backup are listed instead.
+
+Example:
+  ghsecrets vars list
+  ghsecrets vars list --environment production --format json
+  ghsecrets vars list -b aws`,
+	RunE: runVarsList,
+}
+
+var varsDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a variable from GitHub and from the backup",
+	Long: `Delete an Actions variable from GitHub and, with -b, remove it from the backup.
+You will be asked for confirmation unless --yes is given.
+
+Example:
+  ghsecrets vars delete -k OLD_FLAG -b aws
+  ghsecrets vars delete -k OLD_FLAG --yes`,
+	RunE: runVarsDelete,
+}
+
+var varsRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore GitHub Actions variables from backup",
+	Long: `Restore the Actions variables of the GitHub repository or environment from
+AWS Secrets Manager or GCP Secret Manager.
+
+Example:
+  ghsecrets vars restore -b aws
+  ghsecrets vars restore -b gcp --environment production`,
+	RunE: runVarsRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(varsCmd)
+	varsCmd.AddCommand(varsPushCmd)
+	varsCmd.AddCommand(varsListCmd)
+	varsCmd.AddCommand(varsDeleteCmd)
+	varsCmd.AddCommand(varsRestoreCmd)
+
+	varsCmd.PersistentFlags().StringVarP(&varsBackup, "backup", "b", "", "Backup to use: aws, gcp or none")
+	varsCmd.PersistentFlags().String("owner", "", "GitHub repository owner")
+	varsCmd.PersistentFlags().String("repo", "", "GitHub repository name")
+	varsCmd.PersistentFlags().StringP("environment", "e", "", "GitHub Actions environment of the variables")
+	varsCmd.PersistentFlags().String("aws-region", "us-east-1", "AWS region")
+	varsCmd.PersistentFlags().String("aws-profile", "", "AWS profile name")
+	varsCmd.PersistentFlags().String("gcp-project", "", "GCP project ID")
+
+	varsPushCmd.Flags().StringVarP(&varsKey, "key", "k", "", "Variable name (will prompt if not provided)")
+	varsPushCmd.Flags().StringVarP(&varsValue, "value", "v", "", "Variable value (will prompt if not provided)")
+
+	varsListCmd.Flags().StringVar(&varsFormat, "format", "table", "Output format: table or json")
+
+	varsDeleteCmd.Flags().StringVarP(&varsKey, "key", "k", "", "Variable name to delete (required)")
+	varsDeleteCmd.Flags().BoolVarP(&varsYes, "yes", "y", false, "Skip the confirmation prompt")
+	varsDeleteCmd.MarkFlagRequired("key")
+}
+
+// variablesBackupSection returns the bundle section holding the variables of the configured GitHub target
+func variablesBackupSection() string {
+	return bundle.VariablesSection(backupSection())
+}
+
+// openVariablesBackup opens the variables section of the backup selected with -b
+func openVariablesBackup() (backupStore, string, func() error, error) {
+	if _, ok := backupNames[varsBackup]; !ok {
+		return nil, "", nil, fmt.Errorf("invalid backup source: %s (must be aws or gcp)", varsBackup)
+	}
+
+	sections, secretName, closeStore, err := openBackupSections(varsBackup)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return sections(variablesBackupSection()), secretName, closeStore, nil
+}
+
+func runVarsPush(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	if varsKey == "" {
+		if varsKey, err = prompt(reader, "Enter variable name: "); err != nil {
+			return fmt.Errorf("failed to read variable name: %w", err)
+		}
+		if varsKey == "" {
+			return fmt.Errorf("variable name cannot be empty")
+		}
+	}
+	if varsValue == "" {
+		if varsValue, err = prompt(reader, fmt.Sprintf("Enter value for variable '%s': ", varsKey)); err != nil {
+			return fmt.Errorf("failed to read variable value: %w", err)
+		}
+		if varsValue == "" {
+			return fmt.Errorf("variable value cannot be empty")
+		}
+	}
+
+	if varsBackup != "" && varsBackup != "none" {
+		store, _, closeStore, err := openVariablesBackup()
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		fmt.Printf("Creating backup for variable '%s'...\n", varsKey)
+		if err := store.AddOrUpdateKey(ctx, varsKey, varsValue); err != nil {
+			return fmt.Errorf("failed to backup to %s: %w", strings.ToUpper(varsBackup), err)
+		}
+		fmt.Printf("✓ Successfully backed up to %s\n", backupNames[varsBackup])
+	}
+
+	fmt.Printf("Pushing variable '%s' to %s...\n", varsKey, githubTargetName(githubOwner, githubRepo))
+	if err := githubClient.CreateOrUpdateVariable(ctx, varsKey, varsValue); err != nil {
+		return fmt.Errorf("failed to push to GitHub: %w", err)
+	}
+	fmt.Println("✓ Successfully pushed to GitHub Variables")
+
+	return nil
+}
+
+// prompt prints the message and returns the trimmed line read from reader
+func prompt(reader *bufio.Reader, message string) (string, error) {
+	fmt.Print(message)
+	input, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+func runVarsList(cmd *cobra.Command, args []string) error {
+	if varsFormat != "table" && varsFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be table or json)", varsFormat)
+	}
+
+	ctx := context.Background()
+
+	if varsBackup != "" && varsBackup != "none" {
+		store, secretName, closeStore, err := openVariablesBackup()
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		keys, err := store.GetAllKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve variables from %s: %w", backupNames[varsBackup], err)
+		}
+
+		listed := listedBackup{
+			Backend:    varsBackup,
+			SecretName: secretName,
+			Keys:       buildListedKeys(keys, true),
+		}
+		if varsFormat == "json" {
+			return writeJSON(os.Stdout, listed)
+		}
+		return writeListTable(os.Stdout, listed, true)
+	}
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	variables, err := githubClient.ListVariables(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+
+	if varsFormat == "json" {
+		return writeJSON(os.Stdout, variables)
+	}
+	return writeVarsTable(os.Stdout, githubTargetName(githubOwner, githubRepo), variables)
+}
+
+func writeVarsTable(w io.Writer, target string, variables []github.Variable) error {
+	fmt.Fprintf(w, "%s (%d variables)\n\n", target, len(variables))
+	if len(variables) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVALUE\tUPDATED")
+	for _, variable := range variables {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", variable.Name, variable.Value, variable.UpdatedAt.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func runVarsDelete(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+	targets := []string{githubTargetName(githubOwner, githubRepo)}
+
+	var store backupStore
+	var backupKeys []string
+	if varsBackup != "" && varsBackup != "none" {
+		candidate, secretName, closeStore, err := openVariablesBackup()
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		// Only the names are needed, so encrypted values are not decrypted
+		keys, err := rawBackupStore(candidate).GetAllKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve variables from %s: %w", backupNames[varsBackup], err)
+		}
+
+		if backupKeys = findBackupKeys(keys, varsKey); len(backupKeys) > 0 {
+			store = candidate
+			targets = append([]string{fmt.Sprintf("%s secret '%s'", backupNames[varsBackup], secretName)}, targets...)
+		} else {
+			fmt.Printf("Variable '%s' not found in %s secret '%s', skipping backup\n", varsKey, backupNames[varsBackup], secretName)
+		}
+	}
+
+	if !varsYes {
+		ok, err := confirm(os.Stdin, fmt.Sprintf("Delete variable '%s' from %s? [y/N]: ", varsKey, strings.Join(targets, " and ")))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	// Remove from the backup first so that a failed GitHub delete can simply be retried
+	if store != nil {
+		fmt.Printf("Removing variable '%s' from backup...\n", varsKey)
+		for _, key := range backupKeys {
+			if err := store.RemoveKey(ctx, key); err != nil {
+				return fmt.Errorf("failed to remove from %s: %w", backupNames[varsBackup], err)
+			}
+		}
+		fmt.Printf("✓ Successfully removed from %s\n", backupNames[varsBackup])
+	}
+
+	fmt.Printf("Deleting variable '%s' from GitHub...\n", varsKey)
+	if err := githubClient.DeleteVariable(ctx, varsKey); err != nil {
+		// A variable that was only left in the backup is gone once the backup is cleaned up
+		if store != nil && github.IsVariableNotFound(err) {
+			fmt.Printf("Variable '%s' not found in GitHub, nothing to delete\n", varsKey)
+			return nil
+		}
+		return fmt.Errorf("failed to delete from GitHub: %w", err)
+	}
+	fmt.Println("✓ Successfully deleted from GitHub Variables")
+
+	return nil
+}
+
+func runVarsRestore(cmd *cobra.Command, args []string) error {
+	if varsBackup == "" {
+		return fmt.Errorf("backup source must be specified with -b flag (aws or gcp)")
+	}
+
+	ctx := context.Background()
+
+	githubClient, githubOwner, githubRepo, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	store, _, closeStore, err := openVariablesBackup()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	variables, err := store.GetAllKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve variables from %s: %w", backupNames[varsBackup], err)
+	}
+
+	if len(variables) == 0 {
+		fmt.Printf("No variables found in %s\n", backupNames[varsBackup])
+		return nil
+	}
+
+	fmt.Printf("Restoring %d variables from %s to %s\n", len(variables), backupNames[varsBackup], githubTargetName(githubOwner, githubRepo))
+	return restoreVariables(ctx, githubClient.CreateOrUpdateVariable, variables, 1)
+}
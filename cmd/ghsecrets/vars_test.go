@@ -0,0 +1,122 @@
+package ghsecrets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/github"
+)
+
+func TestVariablesBackupSection(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	assert.Equal(t, "@vars", variablesBackupSection())
+
+	viper.Set("github.environment", "production")
+	assert.Equal(t, "@vars/environment/production", variablesBackupSection())
+}
+
+func TestWriteVarsTable(t *testing.T) {
+	variables := []github.Variable{
+		{Name: "LOG_LEVEL", Value: "debug", UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeVarsTable(buf, "GitHub repository owner/repo", variables))
+
+	output := buf.String()
+	assert.Contains(t, output, "GitHub repository owner/repo (1 variables)")
+	assert.Contains(t, output, "LOG_LEVEL")
+	assert.Contains(t, output, "debug")
+	assert.Contains(t, output, "2024-02-01T00:00:00Z")
+}
+
+func TestRestoreVariables(t *testing.T) {
+	ctx := context.Background()
+	mock := github.NewMockClient("token", "owner", "repo")
+
+	err := restoreVariables(ctx, mock.CreateOrUpdateVariable, map[string]string{"LOG_LEVEL": "debug", "REGION": "eu-west-1"}, 1)
+	require.NoError(t, err)
+
+	variables, err := mock.ListVariables(ctx)
+	require.NoError(t, err)
+	require.Len(t, variables, 2)
+	assert.Equal(t, "eu-west-1", variables[1].Value)
+
+	mock.SetError("CreateOrUpdateVariable", assert.AnError)
+	err = restoreVariables(ctx, mock.CreateOrUpdateVariable, map[string]string{"LOG_LEVEL": "info"}, 1)
+	assert.EqualError(t, err, "some variables failed to restore")
+}
+
+func TestPrompt(t *testing.T) {
+	value, err := prompt(bufio.NewReader(strings.NewReader("  debug \n")), "Enter value: ")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", value)
+}
+
+func TestVarsDelete(t *testing.T) {
+	ctx := context.Background()
+	defer viper.Reset()
+
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/actions/variables/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		// GitHub matches variable names case-insensitively
+		name := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/owner/repo/actions/variables/")
+		if !strings.EqualFold(name, "REGION") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		deleted = append(deleted, name)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("aws.secret_name", "test-secret")
+	viper.Set("github.owner", "owner")
+	viper.Set("github.repo", "repo")
+	viper.Set("github.token", "test-token")
+	viper.Set("github.base_url", server.URL+"/api/v3/")
+	defer func() { varsKey, varsBackup, varsYes = "", "", false }()
+
+	// -b none deletes from GitHub only, like push
+	varsKey, varsBackup, varsYes = "REGION", "none", true
+	require.NoError(t, runVarsDelete(nil, nil))
+	assert.Equal(t, []string{"REGION"}, deleted)
+
+	varsKey = "MISSING"
+	assert.EqualError(t, runVarsDelete(nil, nil), "failed to delete from GitHub: variable MISSING not found")
+
+	// A variable left only in the backup is removed from it without failing
+	mockAWS := useMockAWS(t)
+	require.NoError(t, mockAWS.CreateOrUpdateSecret(ctx, "test-secret", `{"@vars":{"MISSING":"x","LOG_LEVEL":"debug","REGION":"eu-west-1"}}`, ""))
+	varsBackup = "aws"
+	require.NoError(t, runVarsDelete(nil, nil))
+
+	// The backup key is found whatever the case of the given name
+	varsKey = "region"
+	require.NoError(t, runVarsDelete(nil, nil))
+	assert.Equal(t, []string{"REGION", "region"}, deleted)
+
+	store, _, closeStore, err := openVariablesBackup()
+	require.NoError(t, err)
+	defer closeStore()
+	variables, err := store.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug"}, variables)
+}
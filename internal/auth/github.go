@@ -7,23 +7,39 @@ import (
 	"strings"
 )
 
+// DefaultHostname is the hostname of github.com, used when no GitHub Enterprise Server is configured
+const DefaultHostname = "github.com"
+
 // GetGitHubToken retrieves GitHub token from multiple sources in order of priority:
 // 1. Viper configuration (passed as parameter)
 // 2. GITHUB_TOKEN environment variable
 // 3. gh CLI token if authenticated
 func GetGitHubToken(configToken string) (string, error) {
+	return GetGitHubTokenForHost(configToken, DefaultHostname)
+}
+
+// GetGitHubTokenForHost is like GetGitHubToken for the given GitHub hostname.
+// For a GitHub Enterprise Server host, GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN
+// are checked before GITHUB_TOKEN, and the gh CLI token is looked up for that host.
+func GetGitHubTokenForHost(configToken, hostname string) (string, error) {
 	// Check viper config first
 	if configToken != "" {
 		return configToken, nil
 	}
 
-	// Check environment variable
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		return token, nil
+	// Check environment variables, using the same enterprise variables as gh CLI
+	envVars := []string{"GITHUB_TOKEN"}
+	if hostname != "" && hostname != DefaultHostname {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GITHUB_TOKEN"}
+	}
+	for _, envVar := range envVars {
+		if token := os.Getenv(envVar); token != "" {
+			return token, nil
+		}
 	}
 
 	// Check gh CLI authentication
-	token, err := getGHCLIToken()
+	token, err := getGHCLITokenForHost(hostname)
 	if err == nil && token != "" {
 		return token, nil
 	}
@@ -36,14 +52,24 @@ func GetGitHubToken(configToken string) (string, error) {
 
 // getGHCLIToken retrieves the token from gh CLI if authenticated
 func getGHCLIToken() (string, error) {
+	return getGHCLITokenForHost("")
+}
+
+// getGHCLITokenForHost retrieves the gh CLI token of the given host, or of the default host when empty
+func getGHCLITokenForHost(hostname string) (string, error) {
+	var hostArgs []string
+	if hostname != "" {
+		hostArgs = []string{"--hostname", hostname}
+	}
+
 	// First check if gh is authenticated
-	cmd := exec.Command("gh", "auth", "status")
+	cmd := exec.Command("gh", append([]string{"auth", "status"}, hostArgs...)...)
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("gh CLI not authenticated")
 	}
 
 	// Get the token
-	cmd = exec.Command("gh", "auth", "token")
+	cmd = exec.Command("gh", append([]string{"auth", "token"}, hostArgs...)...)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get gh token: %w", err)
@@ -95,4 +95,30 @@ func TestGetGHCLIToken(t *testing.T) {
 		// If authenticated, we should get a non-empty token
 		assert.NotEmpty(t, token)
 	}
-}
\ No newline at end of file
+}
+
+func TestGetGitHubTokenForEnterpriseHost(t *testing.T) {
+	for _, envVar := range []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GITHUB_TOKEN"} {
+		original := os.Getenv(envVar)
+		defer os.Setenv(envVar, original)
+		os.Unsetenv(envVar)
+	}
+
+	os.Setenv("GITHUB_TOKEN", "public-token")
+	os.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	// Enterprise hosts prefer the enterprise token
+	token, err := GetGitHubTokenForHost("", "ghes.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "enterprise-token", token)
+
+	// github.com ignores it
+	token, err = GetGitHubTokenForHost("", DefaultHostname)
+	require.NoError(t, err)
+	assert.Equal(t, "public-token", token)
+
+	// The config token still takes priority
+	token, err = GetGitHubTokenForHost("config-token", "ghes.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "config-token", token)
+}
@@ -4,22 +4,71 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/google/uuid"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 )
 
+// ErrVersionConflict is returned when a secret was changed after the version a write is based on
+var ErrVersionConflict = errors.New("secret was modified concurrently")
+
+// versionConflictError is the ErrVersionConflict of a write that had already stored its
+// value as a new version, which is left behind without ever being current
+type versionConflictError struct {
+	versionID  string
+	discarded  string
+	secretName string
+}
+
+func (e *versionConflictError) Error() string {
+	return fmt.Sprintf("%s: %s is no longer the current version of %s", ErrVersionConflict, e.versionID, e.secretName)
+}
+
+func (e *versionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// discardedVersion returns the version left behind by a write that lost a race, if any
+func discardedVersion(err error) (string, bool) {
+	var conflict *versionConflictError
+	if errors.As(err, &conflict) && conflict.discarded != "" {
+		return conflict.discarded, true
+	}
+	return "", false
+}
+
+// pendingVersionStage labels a new version of a secret until it is made current
+const pendingVersionStage = "GHSECRETS_PENDING"
+
 type Client struct {
-	client *secretsmanager.Client
-	region string
+	client   *secretsmanager.Client
+	region   string
+	kmsKeyID string
+	tags     map[string]string
+
+	mu         sync.Mutex
+	reconciled map[string]bool
 }
 
 // ClientOptions contains options for creating an AWS client
 type ClientOptions struct {
 	Region  string
 	Profile string
+	// KMSKeyID is the customer-managed KMS key secrets are encrypted with
+	// (key ID, key ARN, alias name or alias ARN). Empty uses the AWS-managed key.
+	KMSKeyID string
+	// Tags are set on created secrets. When not empty, they are the complete set
+	// of tags: existing secrets are retagged and other tags are removed.
+	Tags map[string]string
 }
 
 // NewClient creates a new AWS Secrets Manager client with the specified region
@@ -46,8 +95,11 @@ func NewClientWithOptions(opts ClientOptions) (*Client, error) {
 	client := secretsmanager.NewFromConfig(cfg)
 
 	return &Client{
-		client: client,
-		region: opts.Region,
+		client:     client,
+		region:     opts.Region,
+		kmsKeyID:   opts.KMSKeyID,
+		tags:       opts.Tags,
+		reconciled: make(map[string]bool),
 	}, nil
 }
 
@@ -64,11 +116,7 @@ func (c *Client) CreateOrUpdateSecret(ctx context.Context, name, value, descript
 		var resourceNotFoundErr *types.ResourceNotFoundException
 		if errors.As(err, &resourceNotFoundErr) {
 			// Secret doesn't exist, try to create it
-			_, createErr := c.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
-				Name:         aws.String(name),
-				SecretString: aws.String(value),
-				Description:  aws.String(description),
-			})
+			_, createErr := c.client.CreateSecret(ctx, c.createSecretInput(name, value, description, ""))
 			if createErr != nil {
 				return fmt.Errorf("failed to create secret: %w", createErr)
 			}
@@ -77,9 +125,124 @@ func (c *Client) CreateOrUpdateSecret(ctx context.Context, name, value, descript
 		return fmt.Errorf("failed to update secret: %w", err)
 	}
 
+	return c.reconcile(ctx, name)
+}
+
+// createSecretInput builds the CreateSecret request with the configured KMS key and tags
+func (c *Client) createSecretInput(name, value, description, token string) *secretsmanager.CreateSecretInput {
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+		Description:  aws.String(description),
+	}
+	if token != "" {
+		input.ClientRequestToken = aws.String(token)
+	}
+	if c.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(c.kmsKeyID)
+	}
+	for key, value := range c.tags {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return input
+}
+
+// reconcile brings the KMS key and tags of an existing secret in line with the options.
+// Each secret is reconciled once per client.
+func (c *Client) reconcile(ctx context.Context, name string) error {
+	if c.kmsKeyID == "" && len(c.tags) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	done := c.reconciled[name]
+	c.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	result, err := c.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe secret: %w", err)
+	}
+
+	if c.kmsKeyID != "" && !sameKMSKey(aws.ToString(result.KmsKeyId), c.kmsKeyID) {
+		_, err := c.client.UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{
+			SecretId: aws.String(name),
+			KmsKeyId: aws.String(c.kmsKeyID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to change KMS key of secret: %w", err)
+		}
+	}
+
+	toAdd, toRemove := tagChanges(result.Tags, c.tags)
+	if len(toAdd) > 0 {
+		_, err := c.client.TagResource(ctx, &secretsmanager.TagResourceInput{
+			SecretId: aws.String(name),
+			Tags:     toAdd,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag secret: %w", err)
+		}
+	}
+	if len(toRemove) > 0 {
+		_, err := c.client.UntagResource(ctx, &secretsmanager.UntagResourceInput{
+			SecretId: aws.String(name),
+			TagKeys:  toRemove,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to untag secret: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.reconciled[name] = true
+	c.mu.Unlock()
 	return nil
 }
 
+// sameKMSKey reports whether the key of a secret, as returned by DescribeSecret, is the
+// configured key, which may be given as an ID, ARN, alias name or alias ARN
+func sameKMSKey(current, configured string) bool {
+	return current == configured ||
+		strings.HasSuffix(current, "/"+configured) ||
+		strings.HasSuffix(current, ":"+configured)
+}
+
+// tagChanges returns the tags to set and the tag keys to remove so that a secret has
+// exactly the desired tags. Tags reserved by AWS ("aws:") are left alone.
+// No desired tags means tags are not managed.
+func tagChanges(current []types.Tag, desired map[string]string) ([]types.Tag, []string) {
+	if len(desired) == 0 {
+		return nil, nil
+	}
+
+	existing := make(map[string]string, len(current))
+	var toRemove []string
+	for _, tag := range current {
+		key := aws.ToString(tag.Key)
+		existing[key] = aws.ToString(tag.Value)
+		if _, ok := desired[key]; !ok && !strings.HasPrefix(key, "aws:") {
+			toRemove = append(toRemove, key)
+		}
+	}
+
+	var toAdd []types.Tag
+	for key, value := range desired {
+		if current, ok := existing[key]; ok && current == value {
+			continue
+		}
+		toAdd = append(toAdd, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+
+	sort.Strings(toRemove)
+	sort.Slice(toAdd, func(i, j int) bool { return aws.ToString(toAdd[i].Key) < aws.ToString(toAdd[j].Key) })
+	return toAdd, toRemove
+}
+
 func (c *Client) GetSecret(ctx context.Context, name string) (string, error) {
 	result, err := c.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(name),
@@ -95,6 +258,154 @@ func (c *Client) GetSecret(ctx context.Context, name string) (string, error) {
 	return "", fmt.Errorf("secret value is empty")
 }
 
+// GetSecretVersion returns the current value of the secret together with its version ID
+func (c *Client) GetSecretVersion(ctx context.Context, name string) (string, string, error) {
+	result, err := c.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if result.SecretString == nil {
+		return "", "", fmt.Errorf("secret value is empty")
+	}
+
+	return *result.SecretString, aws.ToString(result.VersionId), nil
+}
+
+// UpdateSecretIfVersion writes value as a new version of the secret and makes it current
+// only if versionID is still the current version. The new version is staged first under
+// its own ClientRequestToken, then AWSCURRENT is moved from versionID to it, which
+// Secrets Manager refuses when another write moved AWSCURRENT in the meantime.
+func (c *Client) UpdateSecretIfVersion(ctx context.Context, name, value, versionID string) error {
+	// Fix the KMS key and tags first, so a compliance failure doesn't leave a written value behind
+	if err := c.reconcile(ctx, name); err != nil {
+		return err
+	}
+
+	token := uuid.NewString()
+	_, err := c.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:           aws.String(name),
+		SecretString:       aws.String(value),
+		ClientRequestToken: aws.String(token),
+		VersionStages:      []string{pendingVersionStage},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write secret version: %w", err)
+	}
+
+	_, err = c.client.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(name),
+		VersionStage:        aws.String("AWSCURRENT"),
+		MoveToVersionId:     aws.String(token),
+		RemoveFromVersionId: aws.String(versionID),
+	})
+	if err != nil {
+		var invalidParameterErr *types.InvalidParameterException
+		if errors.As(err, &invalidParameterErr) {
+			return &versionConflictError{versionID: versionID, discarded: token, secretName: name}
+		}
+		return fmt.Errorf("failed to make secret version current: %w", err)
+	}
+
+	return nil
+}
+
+// PutSecretPart writes value as a new version of the secret, creating the secret if it
+// doesn't exist, and returns the ID of the new version
+func (c *Client) PutSecretPart(ctx context.Context, name, value, description string) (string, error) {
+	token := uuid.NewString()
+	_, err := c.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:           aws.String(name),
+		SecretString:       aws.String(value),
+		ClientRequestToken: aws.String(token),
+	})
+	if err == nil {
+		return token, c.reconcile(ctx, name)
+	}
+
+	var resourceNotFoundErr *types.ResourceNotFoundException
+	if !errors.As(err, &resourceNotFoundErr) {
+		return "", fmt.Errorf("failed to write secret version: %w", err)
+	}
+
+	_, err = c.client.CreateSecret(ctx, c.createSecretInput(name, value, description, token))
+	if err != nil {
+		return "", fmt.Errorf("failed to create secret: %w", err)
+	}
+	return token, nil
+}
+
+// GetSecretPart returns the value of the given version of the secret
+func (c *Client) GetSecretPart(ctx context.Context, name, versionID string) (string, error) {
+	return c.GetSecretAtVersion(ctx, name, versionID)
+}
+
+// ListSecretVersions returns the versions of the secret, newest first. Versions
+// without a staging label, which AWS keeps until a secret has 100 versions, are included.
+// A version labelled only as pending was written by an UpdateSecretIfVersion that lost
+// a conflict and was never current, so it is left out. Once the next write moves the
+// label, such a version can only be recognized from the bundle (see JSONClient.Versions).
+func (c *Client) ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error) {
+	var versions []bundle.Version
+	paginator := secretsmanager.NewListSecretVersionIdsPaginator(c.client, &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(name),
+		IncludeDeprecated: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secret versions: %w", err)
+		}
+		for _, entry := range page.Versions {
+			if len(entry.VersionStages) == 1 && entry.VersionStages[0] == pendingVersionStage {
+				continue
+			}
+			versions = append(versions, bundle.Version{
+				ID:      aws.ToString(entry.VersionId),
+				Created: aws.ToTime(entry.CreatedDate),
+				Current: slices.Contains(entry.VersionStages, "AWSCURRENT"),
+			})
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Created.After(versions[j].Created) })
+	return versions, nil
+}
+
+// GetSecretAtVersion returns the value of the given version of the secret
+func (c *Client) GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error) {
+	result, err := c.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:  aws.String(name),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret version %s: %w", versionID, err)
+	}
+
+	return aws.ToString(result.SecretString), nil
+}
+
+// GetLastChangedDate returns the time the secret value was last changed
+func (c *Client) GetLastChangedDate(ctx context.Context, name string) (time.Time, error) {
+	result, err := c.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to describe secret: %w", err)
+	}
+
+	if result.LastChangedDate != nil {
+		return *result.LastChangedDate, nil
+	}
+	if result.CreatedDate != nil {
+		return *result.CreatedDate, nil
+	}
+
+	return time.Time{}, nil
+}
+
 func isSecretExistsError(err error) bool {
 	// Check if error indicates that secret already exists
 	var resourceExistsErr *types.ResourceExistsException
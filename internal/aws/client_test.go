@@ -2,12 +2,21 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 )
 
 func TestNewClient(t *testing.T) {
@@ -205,4 +214,145 @@ func TestMockErrorHandling(t *testing.T) {
 	_, err = mockClient.GetSecret(ctx, "test")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "access denied")
-}
\ No newline at end of file
+}
+
+// newTestClient creates a client talking to a fake Secrets Manager endpoint.
+// The handler receives the operation name and the decoded request. It runs on the
+// server's goroutine, so it reports failures with t.Errorf and an error response
+// instead of stopping the test.
+func newTestClient(t *testing.T, opts ClientOptions, handler func(operation string, request map[string]interface{}) (int, string)) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+		var request map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode %s request: %v", operation, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		status, body := handler(operation, request)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return &Client{
+		client: secretsmanager.New(secretsmanager.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(server.URL),
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+		region:     "us-east-1",
+		kmsKeyID:   opts.KMSKeyID,
+		tags:       opts.Tags,
+		reconciled: make(map[string]bool),
+	}
+}
+
+func TestCreateSecretWithKMSKeyAndTags(t *testing.T) {
+	var created map[string]interface{}
+	client := newTestClient(t, ClientOptions{
+		KMSKeyID: "alias/ghsecrets",
+		Tags:     map[string]string{"Owner": "platform"},
+	}, func(operation string, request map[string]interface{}) (int, string) {
+		switch operation {
+		case "UpdateSecret":
+			return http.StatusBadRequest, `{"__type":"ResourceNotFoundException","message":"not found"}`
+		case "CreateSecret":
+			created = request
+			return http.StatusOK, `{"Name":"backup"}`
+		}
+		t.Errorf("unexpected operation %s", operation)
+		return http.StatusBadRequest, `{"__type":"InvalidRequestException","message":"unexpected operation"}`
+	})
+
+	require.NoError(t, client.CreateOrUpdateSecret(context.Background(), "backup", "{}", "desc"))
+	assert.Equal(t, "alias/ghsecrets", created["KmsKeyId"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Key": "Owner", "Value": "platform"}}, created["Tags"])
+}
+
+func TestReconcileExistingSecret(t *testing.T) {
+	var operations []string
+	requests := make(map[string]map[string]interface{})
+	client := newTestClient(t, ClientOptions{
+		KMSKeyID: "alias/ghsecrets",
+		Tags:     map[string]string{"Owner": "platform", "CostCenter": "1234"},
+	}, func(operation string, request map[string]interface{}) (int, string) {
+		operations = append(operations, operation)
+		requests[operation] = request
+		if operation == "DescribeSecret" {
+			return http.StatusOK, `{"Name":"backup","Tags":[{"Key":"Owner","Value":"old"},{"Key":"Stale","Value":"x"},{"Key":"aws:cloudformation:stack-name","Value":"s"}]}`
+		}
+		return http.StatusOK, `{}`
+	})
+
+	ctx := context.Background()
+	require.NoError(t, client.CreateOrUpdateSecret(ctx, "backup", "{}", "desc"))
+	assert.Equal(t, []string{"UpdateSecret", "DescribeSecret", "UpdateSecret", "TagResource", "UntagResource"}, operations)
+	assert.Equal(t, "alias/ghsecrets", requests["UpdateSecret"]["KmsKeyId"])
+	assert.Equal(t, []interface{}{"Stale"}, requests["UntagResource"]["TagKeys"])
+
+	// Each secret is reconciled once per client
+	operations = nil
+	require.NoError(t, client.CreateOrUpdateSecret(ctx, "backup", "{}", "desc"))
+	assert.Equal(t, []string{"UpdateSecret"}, operations)
+}
+
+func TestTagChanges(t *testing.T) {
+	current := []types.Tag{
+		{Key: aws.String("Owner"), Value: aws.String("platform")},
+		{Key: aws.String("CostCenter"), Value: aws.String("old")},
+		{Key: aws.String("Stale"), Value: aws.String("x")},
+		{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("stack")},
+	}
+
+	toAdd, toRemove := tagChanges(current, map[string]string{"Owner": "platform", "CostCenter": "1234", "Team": "infra"})
+	assert.Equal(t, []types.Tag{
+		{Key: aws.String("CostCenter"), Value: aws.String("1234")},
+		{Key: aws.String("Team"), Value: aws.String("infra")},
+	}, toAdd)
+	assert.Equal(t, []string{"Stale"}, toRemove)
+
+	// Without configured tags the tags are left alone
+	toAdd, toRemove = tagChanges(current, nil)
+	assert.Empty(t, toAdd)
+	assert.Empty(t, toRemove)
+}
+
+func TestSameKMSKey(t *testing.T) {
+	arn := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	assert.True(t, sameKMSKey(arn, arn))
+	assert.True(t, sameKMSKey(arn, "1234abcd-12ab-34cd-56ef-1234567890ab"))
+	assert.True(t, sameKMSKey("arn:aws:kms:us-east-1:123456789012:alias/ghsecrets", "alias/ghsecrets"))
+	assert.False(t, sameKMSKey("", "alias/ghsecrets"))
+	assert.False(t, sameKMSKey(arn, "other-key"))
+}
+
+func TestListSecretVersionsSkipsPendingVersions(t *testing.T) {
+	client := newTestClient(t, ClientOptions{}, func(operation string, request map[string]interface{}) (int, string) {
+		assert.Equal(t, "ListSecretVersionIds", operation)
+		assert.Equal(t, true, request["IncludeDeprecated"])
+		return http.StatusOK, `{"Versions":[
+			{"VersionId":"v1","CreatedDate":1700000000,"VersionStages":[]},
+			{"VersionId":"v2","CreatedDate":1700000100,"VersionStages":["AWSPREVIOUS"]},
+			{"VersionId":"lost","CreatedDate":1700000150,"VersionStages":["GHSECRETS_PENDING"]},
+			{"VersionId":"v3","CreatedDate":1700000200,"VersionStages":["AWSCURRENT","GHSECRETS_PENDING"]}
+		]}`
+	})
+
+	versions, err := client.ListSecretVersions(context.Background(), "backup")
+	require.NoError(t, err)
+
+	ids := make([]string, len(versions))
+	for i, version := range versions {
+		ids[i] = version.ID
+	}
+	assert.Equal(t, []string{"v3", "v2", "v1"}, ids)
+	assert.True(t, versions[0].Current)
+
+	// The version that lost the conflict was never current, so it is not picked for its time
+	version, ok := bundle.VersionAt(versions, time.Unix(1700000160, 0))
+	require.True(t, ok)
+	assert.Equal(t, "v2", version.ID)
+}
@@ -1,9 +1,29 @@
 package aws
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/tom-023/ghsecrets/internal/bundle"
+)
 
 // SecretClient defines the interface for secret operations
 type SecretClient interface {
 	CreateOrUpdateSecret(ctx context.Context, name, value, description string) error
 	GetSecret(ctx context.Context, name string) (string, error)
-}
\ No newline at end of file
+	GetLastChangedDate(ctx context.Context, name string) (time.Time, error)
+}
+
+// VersionedSecretClient is implemented by clients that can detect concurrent writes.
+// UpdateSecretIfVersion only makes the new value current if versionID, as returned by
+// GetSecretVersion, is still the current version, and returns ErrVersionConflict otherwise.
+type VersionedSecretClient interface {
+	GetSecretVersion(ctx context.Context, name string) (value, versionID string, err error)
+	UpdateSecretIfVersion(ctx context.Context, name, value, versionID string) error
+}
+
+// HistoryClient is implemented by clients that can read earlier versions of a secret
+type HistoryClient interface {
+	ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error)
+	GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error)
+}
@@ -2,18 +2,38 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"strings"
+	"time"
 	
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 )
 
-// JSONClient wraps the AWS client to store multiple key-value pairs in a single secret
+// maxDiscardedVersions bounds the discarded versions recorded in the bundle. AWS keeps
+// about 100 versions of a secret, so older ones are gone anyway.
+const maxDiscardedVersions = 100
+
+// maxUpdateAttempts bounds how often a write that lost a race is merged and retried
+const maxUpdateAttempts = 5
+
+// conflictBackoff is the base delay before retrying a write that lost a race
+var conflictBackoff = 200 * time.Millisecond
+
+// JSONClient wraps the AWS client to store multiple key-value pairs in a single secret.
+// Keys are read and written in one section of the bundle (see package bundle);
+// the zero section holds repository-scoped secrets.
 type JSONClient struct {
 	client     SecretClient
 	secretName string
+	section    string
+	// versionID pins reads to an earlier version of the secret; such a client is read-only
+	versionID string
+	// createMissing treats a missing secret as empty and creates it on first write
+	createMissing bool
 }
 
 // NewJSONClient creates a new client that stores secrets as JSON
@@ -21,54 +41,219 @@ func NewJSONClient(client SecretClient, secretName string) *JSONClient {
 	return &JSONClient{
 		client:     client,
 		secretName: secretName,
+		section:    bundle.RootSection,
+	}
+}
+
+// Section returns a client that reads and writes the given section of the same secret
+func (j *JSONClient) Section(section string) *JSONClient {
+	return &JSONClient{
+		client:        j.client,
+		secretName:    j.secretName,
+		section:       section,
+		versionID:     j.versionID,
+		createMissing: j.createMissing,
+	}
+}
+
+// AtVersion returns a read-only client for an earlier version of the secret,
+// as listed by Versions
+func (j *JSONClient) AtVersion(versionID string) *JSONClient {
+	return &JSONClient{
+		client:        j.client,
+		secretName:    j.secretName,
+		section:       j.section,
+		versionID:     versionID,
+		createMissing: j.createMissing,
+	}
+}
+
+// CreateIfMissing returns a client that reads a missing secret as empty and creates it
+// on first write, like the GCP client does
+func (j *JSONClient) CreateIfMissing() *JSONClient {
+	return &JSONClient{
+		client:        j.client,
+		secretName:    j.secretName,
+		section:       j.section,
+		versionID:     j.versionID,
+		createMissing: true,
 	}
 }
 
+// Versions lists the stored versions of the secret, newest first. Versions written
+// by a write that lost a race are left out, as they were never current.
+func (j *JSONClient) Versions(ctx context.Context) ([]bundle.Version, error) {
+	history, ok := j.client.(HistoryClient)
+	if !ok {
+		return nil, fmt.Errorf("version history is not available for secret '%s'", j.secretName)
+	}
+
+	versions, err := history.ListSecretVersions(ctx, j.secretName)
+	if err != nil {
+		return nil, j.wrapGetSecretError(err)
+	}
+
+	doc, err := j.AtVersion("").load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discarded := doc.Keys(bundle.DiscardedVersionsSection)
+	if len(discarded) == 0 {
+		return versions, nil
+	}
+
+	kept := make([]bundle.Version, 0, len(versions))
+	for _, version := range versions {
+		if _, ok := discarded[version.ID]; !ok {
+			kept = append(kept, version)
+		}
+	}
+	return kept, nil
+}
+
 // AddOrUpdateKey adds or updates a key-value pair in the JSON secret
 func (j *JSONClient) AddOrUpdateKey(ctx context.Context, key, value string) error {
-	// First check if the secret exists
-	existingJSON, err := j.client.GetSecret(ctx, j.secretName)
-	if err != nil {
-		return j.wrapGetSecretError(err)
+	return j.AddOrUpdateKeys(ctx, map[string]string{key: value})
+}
+
+// AddOrUpdateKeys adds or updates several key-value pairs with a single read and write of the secret
+func (j *JSONClient) AddOrUpdateKeys(ctx context.Context, keys map[string]string) error {
+	return j.update(ctx, func(doc *bundle.Document) error {
+		for key, value := range keys {
+			doc.Set(j.section, key, value)
+		}
+		return nil
+	})
+}
+
+// RemoveKey removes a key from the JSON secret
+func (j *JSONClient) RemoveKey(ctx context.Context, key string) error {
+	return j.update(ctx, func(doc *bundle.Document) error {
+		if !doc.Delete(j.section, key) {
+			return fmt.Errorf("key %s not found in secret", key)
+		}
+		return nil
+	})
+}
+
+// update applies change to the bundle and writes it back. When the client can detect
+// concurrent writes, a write based on an outdated version is not committed; the change is
+// applied again to a fresh read instead, so keys written by others in between are kept.
+func (j *JSONClient) update(ctx context.Context, change func(doc *bundle.Document) error) error {
+	if j.versionID != "" {
+		return fmt.Errorf("version %s of secret '%s' is read-only", j.versionID, j.secretName)
 	}
-	
-	// Parse existing secret data
-	secretData := make(map[string]string)
-	if existingJSON != "" {
-		if err := json.Unmarshal([]byte(existingJSON), &secretData); err != nil {
-			// If unmarshaling fails, it might not be JSON format
-			// Return error instead of starting fresh
-			return fmt.Errorf("secret '%s' exists but is not in valid JSON format: %w", j.secretName, err)
+
+	versioned, ok := j.client.(VersionedSecretClient)
+	if !ok {
+		doc, err := j.load(ctx)
+		if err != nil {
+			return err
+		}
+		if err := change(doc); err != nil {
+			return err
 		}
+		return j.save(ctx, doc)
 	}
-	
-	// Add or update the key
-	secretData[key] = value
-	
-	// Marshal back to JSON
-	updatedJSON, err := json.MarshalIndent(secretData, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal secret data: %w", err)
+
+	// Versions left behind by attempts that lost the race are recorded by the write that
+	// succeeds, so history doesn't show them once a later write moves their pending label
+	discarded := make(map[string]string)
+	for attempt := 1; ; attempt++ {
+		existingJSON, versionID, err := versioned.GetSecretVersion(ctx, j.secretName)
+		if err != nil {
+			if j.createMissing && isSecretNotFoundError(err) {
+				return j.create(ctx, change)
+			}
+			return j.wrapGetSecretError(err)
+		}
+
+		bundleJSON, err := j.decode(ctx, existingJSON)
+		if err != nil {
+			return err
+		}
+		doc, err := j.parse(bundleJSON)
+		if err != nil {
+			return err
+		}
+		if err := change(doc); err != nil {
+			return err
+		}
+		recordDiscardedVersions(doc, discarded)
+
+		updatedJSON, err := j.marshal(ctx, doc)
+		if err != nil {
+			return err
+		}
+
+		err = versioned.UpdateSecretIfVersion(ctx, j.secretName, updatedJSON, versionID)
+		if err == nil {
+			return j.emptyUnusedParts(ctx, existingJSON, updatedJSON)
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		if id, ok := discardedVersion(err); ok {
+			discarded[id] = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+		if attempt == maxUpdateAttempts {
+			return fmt.Errorf("secret '%s' kept changing while updating it, giving up after %d attempts: %w", j.secretName, attempt, err)
+		}
+
+		// Back off with jitter so concurrent writers don't collide again
+		delay := time.Duration(attempt)*conflictBackoff + time.Duration(rand.Int63n(int64(conflictBackoff)+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// create applies change to an empty bundle and writes it as a new secret
+func (j *JSONClient) create(ctx context.Context, change func(doc *bundle.Document) error) error {
+	doc := bundle.New()
+	if err := change(doc); err != nil {
+		return err
+	}
+	return j.save(ctx, doc)
+}
+
+// recordDiscardedVersions adds the discarded versions to the bundle, dropping the
+// oldest records beyond maxDiscardedVersions
+func recordDiscardedVersions(doc *bundle.Document, discarded map[string]string) {
+	if len(discarded) == 0 {
+		return
+	}
+	for id, at := range discarded {
+		doc.Set(bundle.DiscardedVersionsSection, id, at)
+	}
+
+	recorded := doc.Keys(bundle.DiscardedVersionsSection)
+	if len(recorded) <= maxDiscardedVersions {
+		return
+	}
+
+	ids := make([]string, 0, len(recorded))
+	times := make(map[string]time.Time, len(recorded))
+	for id, at := range recorded {
+		ids = append(ids, id)
+		times[id], _ = time.Parse(time.RFC3339Nano, at)
+	}
+	sort.Slice(ids, func(a, b int) bool { return times[ids[a]].After(times[ids[b]]) })
+	for _, id := range ids[maxDiscardedVersions:] {
+		doc.Delete(bundle.DiscardedVersionsSection, id)
 	}
-	
-	// Update the secret
-	description := fmt.Sprintf("GitHub Secrets backup (JSON format)")
-	return j.client.CreateOrUpdateSecret(ctx, j.secretName, string(updatedJSON), description)
 }
 
 // GetKey retrieves a specific key from the JSON secret
 func (j *JSONClient) GetKey(ctx context.Context, key string) (string, error) {
-	existingJSON, err := j.client.GetSecret(ctx, j.secretName)
+	doc, err := j.load(ctx)
 	if err != nil {
-		return "", j.wrapGetSecretError(err)
+		return "", err
 	}
 	
-	var secretData map[string]string
-	if err := json.Unmarshal([]byte(existingJSON), &secretData); err != nil {
-		return "", fmt.Errorf("failed to unmarshal secret data: %w", err)
-	}
-	
-	value, exists := secretData[key]
+	value, exists := doc.Get(j.section, key)
 	if !exists {
 		return "", fmt.Errorf("key %s not found in secret", key)
 	}
@@ -78,17 +263,89 @@ func (j *JSONClient) GetKey(ctx context.Context, key string) (string, error) {
 
 // GetAllKeys retrieves all key-value pairs from the JSON secret
 func (j *JSONClient) GetAllKeys(ctx context.Context) (map[string]string, error) {
-	existingJSON, err := j.client.GetSecret(ctx, j.secretName)
+	doc, err := j.load(ctx)
 	if err != nil {
+		return nil, err
+	}
+	
+	return doc.Keys(j.section), nil
+}
+
+// load reads the secret and parses it as a bundle
+func (j *JSONClient) load(ctx context.Context) (*bundle.Document, error) {
+	existingJSON, err := j.read(ctx)
+	if err != nil {
+		if j.createMissing && j.versionID == "" && isSecretNotFoundError(err) {
+			return bundle.New(), nil
+		}
+		var resourceNotFoundErr *types.ResourceNotFoundException
+		if j.versionID != "" && errors.As(err, &resourceNotFoundErr) {
+			return nil, fmt.Errorf("version %s of AWS Secrets Manager secret '%s' not found", j.versionID, j.secretName)
+		}
 		return nil, j.wrapGetSecretError(err)
 	}
 	
-	var secretData map[string]string
-	if err := json.Unmarshal([]byte(existingJSON), &secretData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal secret data: %w", err)
+	bundleJSON, err := j.decode(ctx, existingJSON)
+	if err != nil {
+		return nil, err
+	}
+	
+	return j.parse(bundleJSON)
+}
+
+// read returns the current value of the secret, or the pinned version
+func (j *JSONClient) read(ctx context.Context) (string, error) {
+	if j.versionID == "" {
+		return j.client.GetSecret(ctx, j.secretName)
+	}
+
+	history, ok := j.client.(HistoryClient)
+	if !ok {
+		return "", fmt.Errorf("version history is not available for secret '%s'", j.secretName)
+	}
+	return history.GetSecretAtVersion(ctx, j.secretName, j.versionID)
+}
+
+// parse parses the secret value as a bundle
+func (j *JSONClient) parse(existingJSON string) (*bundle.Document, error) {
+	doc, err := bundle.Parse(existingJSON)
+	if err != nil {
+		// If parsing fails, it might not be JSON format
+		// Return error instead of starting fresh
+		return nil, fmt.Errorf("secret '%s' exists but is not in valid JSON format: %w", j.secretName, err)
 	}
 	
-	return secretData, nil
+	return doc, nil
+}
+
+// save writes the whole bundle back to the secret
+func (j *JSONClient) save(ctx context.Context, doc *bundle.Document) error {
+	updatedJSON, err := j.marshal(ctx, doc)
+	if err != nil {
+		return err
+	}
+	
+	description := "GitHub Secrets backup (JSON format)"
+	return j.client.CreateOrUpdateSecret(ctx, j.secretName, updatedJSON, description)
+}
+
+// marshal serializes the bundle for the main secret, splitting it into part secrets
+// when it exceeds MaxSecretSize
+func (j *JSONClient) marshal(ctx context.Context, doc *bundle.Document) (string, error) {
+	bundleJSON, err := doc.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return j.encode(ctx, bundleJSON)
+}
+
+// LastUpdated returns the time the JSON secret was last written
+func (j *JSONClient) LastUpdated(ctx context.Context) (time.Time, error) {
+	changed, err := j.client.GetLastChangedDate(ctx, j.secretName)
+	if err != nil {
+		return time.Time{}, j.wrapGetSecretError(err)
+	}
+	return changed, nil
 }
 
 // wrapGetSecretError wraps GetSecret errors with more meaningful messages
@@ -116,4 +373,9 @@ func (j *JSONClient) wrapGetSecretError(err error) error {
 	
 	// For any other error, return it as-is
 	return fmt.Errorf("failed to access AWS Secrets Manager: %w", err)
-}
\ No newline at end of file
+}
+
+func isSecretNotFoundError(err error) bool {
+	var resourceNotFoundErr *types.ResourceNotFoundException
+	return errors.As(err, &resourceNotFoundErr)
+}
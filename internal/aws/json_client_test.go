@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 )
 
 func TestJSONClient_AddOrUpdateKey(t *testing.T) {
@@ -114,6 +116,28 @@ func TestJSONClient_NonExistentSecret(t *testing.T) {
 	assert.Contains(t, err.Error(), "AWS Secrets Manager secret 'non-existent-secret' not found")
 }
 
+func TestJSONClient_CreateIfMissing(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	jsonClient := NewJSONClient(mockClient, "new-secret").CreateIfMissing()
+
+	// A missing secret reads as empty
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// and is created on first write
+	require.NoError(t, jsonClient.Section(bundle.VariablesSection(bundle.RootSection)).AddOrUpdateKey(ctx, "REGION", "eu-west-1"))
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "key1", "value1"))
+
+	keys, err = NewJSONClient(mockClient, "new-secret").GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key1": "value1"}, keys)
+	value, err := NewJSONClient(mockClient, "new-secret").Section(bundle.VariablesSection(bundle.RootSection)).GetKey(ctx, "REGION")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", value)
+}
+
 func TestJSONClient_InvalidJSONFormat(t *testing.T) {
 	ctx := context.Background()
 	mockClient := NewMockClient()
@@ -190,4 +214,219 @@ func TestJSONClient_AuthenticationErrors(t *testing.T) {
 			mockClient.SetError("GetSecret", nil)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestJSONClient_LastUpdated(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	before := time.Now()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", "{}", "test")
+
+	changed, err := jsonClient.LastUpdated(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed.Before(before))
+
+	// A missing secret is reported the same way as for reads
+	_, err = NewJSONClient(mockClient, "missing-secret").LastUpdated(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AWS Secrets Manager secret 'missing-secret' not found")
+}
+
+func TestJSONClient_RemoveKey(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	// Setup test data
+	jsonData, _ := json.Marshal(map[string]string{"key1": "value1", "key2": "value2"})
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", string(jsonData), "test")
+
+	// Test removing an existing key
+	err := jsonClient.RemoveKey(ctx, "key1")
+	require.NoError(t, err)
+
+	allKeys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key2": "value2"}, allKeys)
+
+	// Test removing a non-existent key
+	err = jsonClient.RemoveKey(ctx, "key1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "key key1 not found")
+}
+
+func TestJSONClient_Section(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	envClient := jsonClient.Section(bundle.EnvironmentSection("production"))
+
+	// Setup a bundle in the original flat layout
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"repo-value"}`, "test")
+
+	// Environment keys are stored apart from repository keys
+	err := envClient.AddOrUpdateKey(ctx, "API_KEY", "prod-value")
+	require.NoError(t, err)
+
+	repoKeys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "repo-value"}, repoKeys)
+
+	envKeys, err := envClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "prod-value"}, envKeys)
+
+	// Removing the environment key leaves the repository key untouched
+	require.NoError(t, envClient.RemoveKey(ctx, "API_KEY"))
+	value, err := jsonClient.GetKey(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "repo-value", value)
+}
+
+// countingClient counts the writes made through the wrapped client
+type countingClient struct {
+	SecretClient
+	writes int
+}
+
+func (c *countingClient) CreateOrUpdateSecret(ctx context.Context, name, value, description string) error {
+	c.writes++
+	return c.SecretClient.CreateOrUpdateSecret(ctx, name, value, description)
+}
+
+func TestJSONClient_AddOrUpdateKeys(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"old"}`, "test")
+
+	counting := &countingClient{SecretClient: mockClient}
+	jsonClient := NewJSONClient(counting, "test-secret")
+
+	err := jsonClient.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "new", "TOKEN": "abc", "URL": "https://example.com"})
+	require.NoError(t, err)
+
+	// All keys are written with a single update of the secret
+	assert.Equal(t, 1, counting.writes)
+
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "new", "TOKEN": "abc", "URL": "https://example.com"}, keys)
+}
+
+// racingClient writes another key to the secret right before each of the first races
+// conditional writes, as a teammate pushing at the same time would
+type racingClient struct {
+	*MockClient
+	races int
+}
+
+func (c *racingClient) UpdateSecretIfVersion(ctx context.Context, name, value, versionID string) error {
+	if c.races > 0 {
+		c.races--
+		concurrent := NewJSONClient(c.MockClient, name)
+		if err := concurrent.AddOrUpdateKey(ctx, fmt.Sprintf("CONCURRENT_%d", c.races), "value"); err != nil {
+			return err
+		}
+	}
+	return c.MockClient.UpdateSecretIfVersion(ctx, name, value, versionID)
+}
+
+func TestJSONClient_ConcurrentUpdateIsMerged(t *testing.T) {
+	defer func(backoff time.Duration) { conflictBackoff = backoff }(conflictBackoff)
+	conflictBackoff = 0
+
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"old"}`, "test")
+
+	jsonClient := NewJSONClient(&racingClient{MockClient: mockClient, races: 2}, "test-secret")
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "DB_PASSWORD", "secret"))
+
+	// Neither the concurrent writes nor ours are lost
+	keys, err := NewJSONClient(mockClient, "test-secret").GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"API_KEY":      "old",
+		"CONCURRENT_0": "value",
+		"CONCURRENT_1": "value",
+		"DB_PASSWORD":  "secret",
+	}, keys)
+}
+
+func TestJSONClient_ConcurrentUpdateGivesUp(t *testing.T) {
+	defer func(backoff time.Duration) { conflictBackoff = backoff }(conflictBackoff)
+	conflictBackoff = 0
+
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	jsonClient := NewJSONClient(&racingClient{MockClient: mockClient, races: maxUpdateAttempts}, "test-secret")
+	err := jsonClient.AddOrUpdateKey(ctx, "DB_PASSWORD", "secret")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.ErrorContains(t, err, "giving up after 5 attempts")
+
+	keys, err := NewJSONClient(mockClient, "test-secret").GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, keys, "DB_PASSWORD")
+}
+
+func TestJSONClient_VersionsSkipDiscardedWrites(t *testing.T) {
+	defer func(backoff time.Duration) { conflictBackoff = backoff }(conflictBackoff)
+	conflictBackoff = 0
+
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	// The first attempt loses the race and leaves version v3 behind
+	racing := NewJSONClient(&racingClient{MockClient: mockClient, races: 1}, "test-secret")
+	require.NoError(t, racing.AddOrUpdateKey(ctx, "API_KEY", "ours"))
+
+	// The next write moves the pending label away from v3
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "TOKEN", "abc"))
+
+	versions, err := jsonClient.Versions(ctx)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(versions))
+	for _, version := range versions {
+		ids = append(ids, version.ID)
+	}
+	assert.Equal(t, []string{"v5", "v4", "v2", "v1"}, ids)
+
+	// The record doesn't show up as keys
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "ours", "CONCURRENT_0": "value", "TOKEN": "abc"}, keys)
+}
+
+func TestJSONClient_Versions(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "API_KEY", "good"))
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "API_KEY", "bad"))
+
+	versions, err := jsonClient.Versions(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.True(t, versions[0].Current)
+	assert.False(t, versions[1].Current)
+
+	// An earlier version is read through a pinned, read-only client
+	previous := jsonClient.AtVersion(versions[1].ID)
+	value, err := previous.GetKey(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "good", value)
+
+	err = previous.AddOrUpdateKey(ctx, "API_KEY", "other")
+	assert.EqualError(t, err, fmt.Sprintf("version %s of secret 'test-secret' is read-only", versions[1].ID))
+
+	_, err = jsonClient.AtVersion("missing").GetAllKeys(ctx)
+	assert.EqualError(t, err, "version missing of AWS Secrets Manager secret 'test-secret' not found")
+}
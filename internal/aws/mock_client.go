@@ -4,22 +4,42 @@ import (
 	"context"
 	"fmt"
 	"sync"
-	
+	"time"
+
+
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/tom-023/ghsecrets/internal/bundle"
 )
 
+// mockVersion is a stored version of a mock secret
+type mockVersion struct {
+	id      string
+	value   string
+	created time.Time
+}
+
 // MockClient is a mock implementation of AWS Secrets Manager client for testing
 type MockClient struct {
-	mu      sync.Mutex
-	secrets map[string]string
-	errors  map[string]error
+	mu       sync.Mutex
+	secrets  map[string]string
+	versions map[string]int
+	history  map[string][]mockVersion
+	current  map[string]string
+	pending  map[string]string
+	changed  map[string]time.Time
+	errors   map[string]error
 }
 
 // NewMockClient creates a new mock AWS client
 func NewMockClient() *MockClient {
 	return &MockClient{
-		secrets: make(map[string]string),
-		errors:  make(map[string]error),
+		secrets:  make(map[string]string),
+		versions: make(map[string]int),
+		history:  make(map[string][]mockVersion),
+		current:  make(map[string]string),
+		pending:  make(map[string]string),
+		changed:  make(map[string]time.Time),
+		errors:   make(map[string]error),
 	}
 }
 
@@ -39,17 +59,169 @@ func (m *MockClient) CreateOrUpdateSecret(ctx context.Context, name, value, desc
 		return err
 	}
 
-	// Simulate "already exists" error if secret exists and we're in create mode
-	if _, exists := m.secrets[name]; exists && m.errors["CreateSecret"] != nil {
-		// Update the secret instead
-		m.secrets[name] = value
-		return nil
+	// Creating and updating both write a new version
+	_, err := m.write(name, value)
+	return err
+}
+
+// write stores value as a new version of the secret and makes it current.
+// The caller must hold m.mu.
+func (m *MockClient) write(name, value string) (string, error) {
+	versionID, err := m.stage(name, value)
+	if err != nil {
+		return "", err
 	}
 
 	m.secrets[name] = value
+	m.current[name] = versionID
+	m.changed[name] = time.Now()
+	return versionID, nil
+}
+
+// stage stores value as a new version of the secret without making it current,
+// enforcing the size limit of the real service. The caller must hold m.mu.
+func (m *MockClient) stage(name, value string) (string, error) {
+	if len(value) > MaxSecretSize {
+		return "", fmt.Errorf("ValidationException: SecretString of %s exceeds %d bytes", name, MaxSecretSize)
+	}
+
+	m.versions[name]++
+	versionID := fmt.Sprintf("v%d", m.versions[name])
+	m.history[name] = append(m.history[name], mockVersion{id: versionID, value: value, created: time.Now()})
+	return versionID, nil
+}
+
+// GetSecretVersion mocks the GetSecretVersion method
+func (m *MockClient) GetSecretVersion(ctx context.Context, name string) (string, string, error) {
+	value, err := m.GetSecret(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return value, m.current[name], nil
+}
+
+// UpdateSecretIfVersion mocks the UpdateSecretIfVersion method
+func (m *MockClient) UpdateSecretIfVersion(ctx context.Context, name, value, versionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["UpdateSecretIfVersion"]; err != nil {
+		return err
+	}
+
+	// Like the real client, the new version is staged as pending before it is made current,
+	// so a write that loses the race leaves a version behind
+	newVersionID, err := m.stage(name, value)
+	if err != nil {
+		return err
+	}
+	m.pending[name] = newVersionID
+
+	if m.current[name] != versionID {
+		return &versionConflictError{versionID: versionID, discarded: newVersionID, secretName: name}
+	}
+
+	m.secrets[name] = value
+	m.current[name] = newVersionID
+	m.changed[name] = time.Now()
 	return nil
 }
 
+// PutSecretPart mocks the PutSecretPart method
+func (m *MockClient) PutSecretPart(ctx context.Context, name, value, description string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["PutSecretPart"]; err != nil {
+		return "", err
+	}
+
+	return m.write(name, value)
+}
+
+// GetSecretPart mocks the GetSecretPart method
+func (m *MockClient) GetSecretPart(ctx context.Context, name, versionID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["GetSecretPart"]; err != nil {
+		return "", err
+	}
+
+	return m.versionValue(name, versionID)
+}
+
+// versionValue returns the value of a stored version. The caller must hold m.mu.
+func (m *MockClient) versionValue(name, versionID string) (string, error) {
+	for _, version := range m.history[name] {
+		if version.id == versionID {
+			return version.value, nil
+		}
+	}
+	return "", &types.ResourceNotFoundException{
+		Message: &[]string{"Secrets Manager can't find the specified secret value for VersionId: " + versionID}[0],
+	}
+}
+
+// ListSecretVersions mocks the ListSecretVersions method
+func (m *MockClient) ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["ListSecretVersions"]; err != nil {
+		return nil, err
+	}
+
+	history, exists := m.history[name]
+	if !exists {
+		return nil, &types.ResourceNotFoundException{
+			Message: &[]string{"Secrets Manager can't find the specified secret."}[0],
+		}
+	}
+
+	// Only the version that still holds the pending label is recognizable as never current
+	versions := make([]bundle.Version, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		id := history[i].id
+		if id == m.pending[name] && id != m.current[name] {
+			continue
+		}
+		versions = append(versions, bundle.Version{
+			ID:      id,
+			Created: history[i].created,
+			Current: id == m.current[name],
+		})
+	}
+	return versions, nil
+}
+
+// GetSecretAtVersion mocks the GetSecretAtVersion method
+func (m *MockClient) GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["GetSecretAtVersion"]; err != nil {
+		return "", err
+	}
+
+	return m.versionValue(name, versionID)
+}
+
+// SetVersionCreated changes the creation time of a stored version
+func (m *MockClient) SetVersionCreated(name, versionID string, created time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.history[name] {
+		if m.history[name][i].id == versionID {
+			m.history[name][i].created = created
+		}
+	}
+}
+
 // GetSecret mocks the GetSecret method
 func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error) {
 	m.mu.Lock()
@@ -70,3 +242,21 @@ func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error)
 	return value, nil
 }
 
+// GetLastChangedDate mocks the GetLastChangedDate method
+func (m *MockClient) GetLastChangedDate(ctx context.Context, name string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["GetLastChangedDate"]; err != nil {
+		return time.Time{}, err
+	}
+
+	changed, exists := m.changed[name]
+	if !exists {
+		return time.Time{}, &types.ResourceNotFoundException{
+			Message: &[]string{"Secrets Manager can't find the specified secret."}[0],
+		}
+	}
+
+	return changed, nil
+}
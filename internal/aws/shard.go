@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxSecretSize is the largest SecretString AWS Secrets Manager accepts
+const MaxSecretSize = 64 * 1024
+
+// unusedPartValue replaces the bundle in part secrets a shrunk bundle no longer uses.
+// AWS Secrets Manager doesn't accept an empty SecretString.
+const unusedPartValue = "{}"
+
+// manifestKey is the top-level key that marks a secret as a shard manifest
+const manifestKey = "@manifest"
+
+// ShardSecretClient is implemented by clients that can spread a bundle that is too
+// large for one secret over several part secrets
+type ShardSecretClient interface {
+	// PutSecretPart writes value as a new version of the secret, creating the secret
+	// if needed, and returns the ID of the new version
+	PutSecretPart(ctx context.Context, name, value, description string) (versionID string, err error)
+	// GetSecretPart returns the value of the given version of the secret
+	GetSecretPart(ctx context.Context, name, versionID string) (string, error)
+}
+
+// shardManifest is stored in the main secret in place of a bundle that exceeds
+// MaxSecretSize. The bundle JSON is split into parts stored in <secret_name>-part-N;
+// each part is pinned to the version written with the manifest, so a reader never
+// mixes parts of different writes.
+type shardManifest struct {
+	Parts  []shardPart `json:"parts"`
+	Size   int         `json:"size"`
+	SHA256 string      `json:"sha256"`
+}
+
+type shardPart struct {
+	Name      string `json:"name"`
+	VersionID string `json:"version_id"`
+}
+
+// partSecretName returns the name of the n-th part secret (counting from 1)
+func partSecretName(secretName string, n int) string {
+	return fmt.Sprintf("%s-part-%d", secretName, n)
+}
+
+// parseManifest returns the manifest stored in a secret value, or nil if the value is a plain bundle
+func parseManifest(value string) *shardManifest {
+	var doc struct {
+		Manifest *shardManifest `json:"@manifest"`
+	}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		// Not a manifest; parsing the bundle reports the error
+		return nil
+	}
+	return doc.Manifest
+}
+
+// splitParts splits a string into chunks of at most size bytes without splitting a UTF-8 sequence
+func splitParts(value string, size int) []string {
+	var parts []string
+	for len(value) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(value[end]) {
+			end--
+		}
+		parts = append(parts, value[:end])
+		value = value[end:]
+	}
+	return append(parts, value)
+}
+
+func checksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// encode returns the value to store in the main secret for the bundle JSON: the bundle
+// itself when it fits, otherwise a manifest after writing the bundle to part secrets
+func (j *JSONClient) encode(ctx context.Context, bundleJSON string) (string, error) {
+	if len(bundleJSON) <= MaxSecretSize {
+		return bundleJSON, nil
+	}
+
+	shards, ok := j.client.(ShardSecretClient)
+	if !ok {
+		return "", fmt.Errorf("secret '%s' would be %d bytes, over the %d byte limit of AWS Secrets Manager", j.secretName, len(bundleJSON), MaxSecretSize)
+	}
+
+	manifest := shardManifest{Size: len(bundleJSON), SHA256: checksum(bundleJSON)}
+	chunks := splitParts(bundleJSON, MaxSecretSize)
+	for i, chunk := range chunks {
+		name := partSecretName(j.secretName, i+1)
+		description := fmt.Sprintf("GitHub Secrets backup: part %d of %s", i+1, j.secretName)
+		versionID, err := shards.PutSecretPart(ctx, name, chunk, description)
+		if err != nil {
+			return "", fmt.Errorf("bundle is %d bytes, over the %d byte limit of AWS Secrets Manager, and writing part secret '%s' failed: %w", len(bundleJSON), MaxSecretSize, name, err)
+		}
+		manifest.Parts = append(manifest.Parts, shardPart{Name: name, VersionID: versionID})
+	}
+
+	value, err := json.MarshalIndent(map[string]shardManifest{manifestKey: manifest}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// emptyUnusedParts empties the part secrets that the previous value of the main secret
+// used and the new value doesn't, so their current versions don't keep the old bundle
+// after it shrank. The part secrets aren't deleted, as earlier versions of the main
+// secret still point to their earlier versions.
+func (j *JSONClient) emptyUnusedParts(ctx context.Context, previousValue, value string) error {
+	previous := parseManifest(previousValue)
+	if previous == nil {
+		return nil
+	}
+	used := 0
+	if manifest := parseManifest(value); manifest != nil {
+		used = len(manifest.Parts)
+	}
+	if used >= len(previous.Parts) {
+		return nil
+	}
+
+	shards, ok := j.client.(ShardSecretClient)
+	if !ok {
+		return nil
+	}
+	for _, part := range previous.Parts[used:] {
+		description := fmt.Sprintf("GitHub Secrets backup: unused part of %s", j.secretName)
+		if _, err := shards.PutSecretPart(ctx, part.Name, unusedPartValue, description); err != nil {
+			return fmt.Errorf("secret '%s' was written, but emptying its unused part secret '%s' failed: %w", j.secretName, part.Name, err)
+		}
+	}
+	return nil
+}
+
+// decode returns the bundle JSON for a value read from the main secret, reassembling
+// it from the part secrets when the value is a manifest
+func (j *JSONClient) decode(ctx context.Context, value string) (string, error) {
+	manifest := parseManifest(value)
+	if manifest == nil {
+		return value, nil
+	}
+
+	shards, ok := j.client.(ShardSecretClient)
+	if !ok {
+		return "", fmt.Errorf("secret '%s' is split into %d parts, which this client can't read", j.secretName, len(manifest.Parts))
+	}
+
+	bundleJSON := make([]byte, 0, manifest.Size)
+	for _, part := range manifest.Parts {
+		chunk, err := shards.GetSecretPart(ctx, part.Name, part.VersionID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read part secret '%s' of '%s': %w", part.Name, j.secretName, err)
+		}
+		bundleJSON = append(bundleJSON, chunk...)
+	}
+
+	if checksum(string(bundleJSON)) != manifest.SHA256 {
+		return "", fmt.Errorf("part secrets of '%s' don't match its manifest", j.secretName)
+	}
+	return string(bundleJSON), nil
+}
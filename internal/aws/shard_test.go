@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitParts(t *testing.T) {
+	parts := splitParts("abcdefg", 3)
+	assert.Equal(t, []string{"abc", "def", "g"}, parts)
+
+	// Multi-byte characters are never split
+	parts = splitParts("aéé", 2)
+	assert.Equal(t, []string{"a", "é", "é"}, parts)
+	for _, part := range parts {
+		assert.True(t, utf8.ValidString(part))
+	}
+}
+
+func TestJSONClient_ShardsLargeBundle(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"small"}`, "test")
+
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	certificate := strings.Repeat("A", 50*1024)
+	serviceAccount := strings.Repeat("é", 30*1024)
+	require.NoError(t, jsonClient.AddOrUpdateKeys(ctx, map[string]string{"CERTIFICATE": certificate, "SERVICE_ACCOUNT": serviceAccount}))
+
+	// The main secret holds a manifest pointing to the part secrets
+	value, err := mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+	manifest := parseManifest(value)
+	require.NotNil(t, manifest)
+	assert.Len(t, manifest.Parts, 2)
+	assert.Equal(t, "test-secret-part-1", manifest.Parts[0].Name)
+
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "small", "CERTIFICATE": certificate, "SERVICE_ACCOUNT": serviceAccount}, keys)
+
+	// Keys are added to a sharded bundle like to any other
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "TOKEN", "abc"))
+	value, err = jsonClient.GetKey(ctx, "TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	// A bundle that fits again is stored inline
+	require.NoError(t, jsonClient.RemoveKey(ctx, "CERTIFICATE"))
+	require.NoError(t, jsonClient.RemoveKey(ctx, "SERVICE_ACCOUNT"))
+	value, err = mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+	assert.Nil(t, parseManifest(value))
+	assert.JSONEq(t, `{"API_KEY":"small","TOKEN":"abc"}`, value)
+
+	// The part secrets no longer hold the removed values
+	for _, name := range []string{"test-secret-part-1", "test-secret-part-2"} {
+		part, err := mockClient.GetSecret(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, unusedPartValue, part)
+	}
+}
+
+func TestJSONClient_ShrinkingBundleEmptiesUnusedParts(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	first := strings.Repeat("A", 50*1024)
+	second := strings.Repeat("B", 50*1024)
+	third := strings.Repeat("C", 50*1024)
+	require.NoError(t, jsonClient.AddOrUpdateKeys(ctx, map[string]string{"FIRST": first, "SECOND": second, "THIRD": third}))
+	versions, err := jsonClient.Versions(ctx)
+	require.NoError(t, err)
+	sharded := versions[0].ID
+
+	value, err := mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+	require.Len(t, parseManifest(value).Parts, 3)
+
+	// Three parts shrink to two: the third part secret is emptied
+	require.NoError(t, jsonClient.RemoveKey(ctx, "SECOND"))
+	value, err = mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+	require.Len(t, parseManifest(value).Parts, 2)
+
+	part, err := mockClient.GetSecret(ctx, "test-secret-part-3")
+	require.NoError(t, err)
+	assert.Equal(t, unusedPartValue, part)
+
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"FIRST": first, "THIRD": third}, keys)
+
+	// The earlier version still reads the parts it was written with
+	keys, err = jsonClient.AtVersion(sharded).GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"FIRST": first, "SECOND": second, "THIRD": third}, keys)
+}
+
+func TestJSONClient_ShardedPartsArePinned(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	certificate := strings.Repeat("A", 70*1024)
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "CERTIFICATE", certificate))
+
+	// A part rewritten outside of the manifest doesn't change what is read
+	require.NoError(t, mockClient.CreateOrUpdateSecret(ctx, "test-secret-part-1", "garbage", ""))
+	value, err := jsonClient.GetKey(ctx, "CERTIFICATE")
+	require.NoError(t, err)
+	assert.Equal(t, certificate, value)
+}
+
+func TestJSONClient_SizeLimitWithoutSharding(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{}`, "test")
+
+	// countingClient only exposes the basic SecretClient methods
+	jsonClient := NewJSONClient(&countingClient{SecretClient: mockClient}, "test-secret")
+	err := jsonClient.AddOrUpdateKey(ctx, "CERTIFICATE", strings.Repeat("A", 70*1024))
+	assert.ErrorContains(t, err, "over the 65536 byte limit of AWS Secrets Manager")
+}
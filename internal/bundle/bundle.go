@@ -0,0 +1,206 @@
+// Package bundle implements the JSON layout shared by the AWS and GCP backups.
+//
+// Repository-scoped secrets are stored as top-level string values, which is the
+// original flat layout. Every other scope (for example an Actions environment,
+// or the Dependabot and Codespaces secret stores) is stored in its own section:
+// a top-level object whose name starts with "@".
+// GitHub secret names can't contain "@", so sections never clash with keys.
+//
+//	{
+//	  "API_KEY": "...",
+//	  "@environment/production": {
+//	    "DEPLOY_KEY": "..."
+//	  },
+//	  "@org/my-org": {
+//	    "REGISTRY_TOKEN": "..."
+//	  },
+//	  "@org-settings/my-org": {
+//	    "REGISTRY_TOKEN": "selected:123,456"
+//	  },
+//	  "@dependabot": {
+//	    "REGISTRY_TOKEN": "..."
+//	  },
+//	  "@vars": {
+//	    "LOG_LEVEL": "debug"
+//	  }
+//	}
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RootSection is the section holding repository-scoped keys
+const RootSection = ""
+
+const sectionPrefix = "@"
+
+// EnvironmentSection returns the section holding the secrets of an Actions environment
+func EnvironmentSection(environment string) string {
+	return sectionPrefix + "environment/" + environment
+}
+
+// OrgSection returns the section holding the secrets of an organization
+func OrgSection(org string) string {
+	return sectionPrefix + "org/" + org
+}
+
+// OrgSettingsSection returns the section holding the visibility settings of an
+// organization's secrets, keyed by secret name
+func OrgSettingsSection(org string) string {
+	return sectionPrefix + "org-settings/" + org
+}
+
+// StoreSection returns the section holding the secrets of a separate GitHub secret
+// store (for example "dependabot") that correspond to the given Actions section
+func StoreSection(store, section string) string {
+	if section == RootSection {
+		return sectionPrefix + store
+	}
+	return sectionPrefix + store + "/" + strings.TrimPrefix(section, sectionPrefix)
+}
+
+// VariablesSection returns the section holding the Actions variables that belong
+// to the given section, for example the variables of an environment
+func VariablesSection(section string) string {
+	return StoreSection("vars", section)
+}
+
+// DiscardedVersionsSection records the versions of the backup secret that were written
+// by a write that lost a race and never became current, keyed by version ID with the
+// time they were discarded
+const DiscardedVersionsSection = sectionPrefix + "discarded-versions"
+
+// Document is a parsed backup bundle
+type Document struct {
+	sections map[string]map[string]string
+}
+
+// New creates an empty document
+func New() *Document {
+	return &Document{sections: make(map[string]map[string]string)}
+}
+
+// Parse parses the JSON payload of a backup secret. An empty payload yields an empty document.
+func Parse(data string) (*Document, error) {
+	doc := New()
+	if strings.TrimSpace(data) == "" {
+		return doc, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+
+	for name, value := range raw {
+		if strings.HasPrefix(name, sectionPrefix) {
+			var section map[string]string
+			if err := json.Unmarshal(value, &section); err != nil {
+				return nil, fmt.Errorf("section %s: %w", name, err)
+			}
+			doc.sections[name] = section
+			continue
+		}
+
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			return nil, fmt.Errorf("key %s: %w", name, err)
+		}
+		doc.Set(RootSection, name, str)
+	}
+
+	return doc, nil
+}
+
+// Marshal encodes the document back to its JSON payload. Empty sections are dropped.
+func (d *Document) Marshal() (string, error) {
+	out := make(map[string]interface{})
+	for key, value := range d.sections[RootSection] {
+		out[key] = value
+	}
+	for name, section := range d.sections {
+		if name == RootSection || len(section) == 0 {
+			continue
+		}
+		out[name] = section
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal secret data: %w", err)
+	}
+	return string(data), nil
+}
+
+// Keys returns a copy of the key-value pairs of a section
+func (d *Document) Keys(section string) map[string]string {
+	keys := make(map[string]string, len(d.sections[section]))
+	for key, value := range d.sections[section] {
+		keys[key] = value
+	}
+	return keys
+}
+
+// Get returns the value of a key in a section
+func (d *Document) Get(section, key string) (string, bool) {
+	value, exists := d.sections[section][key]
+	return value, exists
+}
+
+// Set adds or updates a key in a section
+func (d *Document) Set(section, key, value string) {
+	if d.sections[section] == nil {
+		d.sections[section] = make(map[string]string)
+	}
+	d.sections[section][key] = value
+}
+
+// Delete removes a key from a section and reports whether it existed
+func (d *Document) Delete(section, key string) bool {
+	if _, exists := d.sections[section][key]; !exists {
+		return false
+	}
+	delete(d.sections[section], key)
+	return true
+}
+
+// Sections returns the names of all non-empty sections in sorted order
+func (d *Document) Sections() []string {
+	names := make([]string, 0, len(d.sections))
+	for name, section := range d.sections {
+		if len(section) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Version describes a stored version of a backup bundle
+type Version struct {
+	ID      string
+	Created time.Time
+	// Current is set on the version that is read by default
+	Current bool
+}
+
+// VersionAt returns the version that was current at the given time: the newest
+// version created at or before it
+func VersionAt(versions []Version, at time.Time) (Version, bool) {
+	var found Version
+	ok := false
+	for _, version := range versions {
+		if version.Created.After(at) {
+			continue
+		}
+		if !ok || version.Created.After(found.Created) {
+			found, ok = version, true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,111 @@
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlatLayout(t *testing.T) {
+	// Bundles written before sections existed only contain top-level keys
+	doc, err := Parse(`{"API_KEY":"value1","TOKEN":"value2"}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"API_KEY": "value1", "TOKEN": "value2"}, doc.Keys(RootSection))
+	assert.Equal(t, []string{RootSection}, doc.Sections())
+}
+
+func TestParseEmpty(t *testing.T) {
+	doc, err := Parse("")
+	require.NoError(t, err)
+	assert.Empty(t, doc.Keys(RootSection))
+	assert.Empty(t, doc.Sections())
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not JSON", data: "not-a-json-string"},
+		{name: "non-string key", data: `{"API_KEY": 1}`},
+		{name: "non-object section", data: `{"@environment/production": "value"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSectionsRoundTrip(t *testing.T) {
+	doc := New()
+	doc.Set(RootSection, "API_KEY", "repo-value")
+	doc.Set(EnvironmentSection("production"), "API_KEY", "prod-value")
+
+	data, err := doc.Marshal()
+	require.NoError(t, err)
+
+	// Environment keys are kept apart from repository keys in the JSON
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &raw))
+	assert.Equal(t, "repo-value", raw["API_KEY"])
+	assert.Equal(t, map[string]interface{}{"API_KEY": "prod-value"}, raw["@environment/production"])
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	value, exists := parsed.Get(EnvironmentSection("production"), "API_KEY")
+	assert.True(t, exists)
+	assert.Equal(t, "prod-value", value)
+	assert.Equal(t, []string{RootSection, "@environment/production"}, parsed.Sections())
+}
+
+func TestDelete(t *testing.T) {
+	doc := New()
+	doc.Set(EnvironmentSection("staging"), "TOKEN", "value")
+
+	assert.True(t, doc.Delete(EnvironmentSection("staging"), "TOKEN"))
+	assert.False(t, doc.Delete(EnvironmentSection("staging"), "TOKEN"))
+	assert.False(t, doc.Delete(RootSection, "MISSING"))
+
+	// Empty sections are dropped when marshaling
+	data, err := doc.Marshal()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, data)
+}
+
+func TestStoreSection(t *testing.T) {
+	assert.Equal(t, "@dependabot", StoreSection("dependabot", RootSection))
+	assert.Equal(t, "@codespaces/org/my-org", StoreSection("codespaces", OrgSection("my-org")))
+	assert.Equal(t, "@dependabot/org-settings/my-org", StoreSection("dependabot", OrgSettingsSection("my-org")))
+}
+
+func TestVariablesSection(t *testing.T) {
+	assert.Equal(t, "@vars", VariablesSection(RootSection))
+	assert.Equal(t, "@vars/environment/production", VariablesSection(EnvironmentSection("production")))
+}
+
+func TestVersionAt(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	versions := []Version{
+		{ID: "3", Created: day(3), Current: true},
+		{ID: "1", Created: day(1)},
+		{ID: "2", Created: day(2)},
+	}
+
+	version, ok := VersionAt(versions, day(2).Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "2", version.ID)
+
+	version, ok = VersionAt(versions, day(3))
+	assert.True(t, ok)
+	assert.Equal(t, "3", version.ID)
+
+	_, ok = VersionAt(versions, day(1).Add(-time.Second))
+	assert.False(t, ok)
+}
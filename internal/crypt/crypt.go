@@ -0,0 +1,293 @@
+// Package crypt seals backup values on the client before they are written to a
+// backend, so a backup is useless without the key held by the user.
+//
+// Each value is encrypted on its own and stored as text in the bundle:
+//
+//	ENC[age,<base64 age file>]          sealed to one or more age recipients
+//	ENC[scrypt,<base64 salt|nonce|ct>]  sealed with a key derived from a passphrase
+//
+// Values that aren't sealed (written before encryption was enabled) are read as is.
+package crypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	schemeAge    = "age"
+	schemeScrypt = "scrypt"
+
+	sealedPrefix = "ENC["
+	sealedSuffix = "]"
+)
+
+// scrypt parameters used to derive the key from a passphrase
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 16
+)
+
+// Sealer encrypts values before they are stored and decrypts them when they are read
+type Sealer interface {
+	Seal(plaintext string) (string, error)
+	Open(value string) (string, error)
+}
+
+// IsSealed reports whether a stored value was sealed by a Sealer
+func IsSealed(value string) bool {
+	_, _, ok := parseSealed(value)
+	return ok
+}
+
+func formatSealed(scheme string, data []byte) string {
+	return sealedPrefix + scheme + "," + base64.StdEncoding.EncodeToString(data) + sealedSuffix
+}
+
+func parseSealed(value string) (string, string, bool) {
+	if !strings.HasPrefix(value, sealedPrefix) || !strings.HasSuffix(value, sealedSuffix) {
+		return "", "", false
+	}
+	scheme, data, ok := strings.Cut(value[len(sealedPrefix):len(value)-len(sealedSuffix)], ",")
+	if !ok || (scheme != schemeAge && scheme != schemeScrypt) {
+		return "", "", false
+	}
+	return scheme, data, true
+}
+
+// open decodes a sealed value of the given scheme. Unsealed values are returned as
+// is; values sealed with another scheme can't be opened.
+func open(value, scheme string) ([]byte, bool, error) {
+	valueScheme, data, ok := parseSealed(value)
+	if !ok {
+		return nil, false, nil
+	}
+	if valueScheme != scheme {
+		return nil, true, fmt.Errorf("value is sealed with %s, but %s encryption is configured", valueScheme, scheme)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid sealed value: %w", err)
+	}
+	return raw, true, nil
+}
+
+// AgeSealer seals values to age recipients and opens them with age identities
+type AgeSealer struct {
+	recipients []age.Recipient
+	identities func() ([]age.Identity, error)
+}
+
+// NewAgeSealer creates a sealer for the given age recipients ("age1..."). Identities are
+// only loaded when a value is opened, so pushing works with the public recipients alone.
+func NewAgeSealer(recipients []string, identities func() ([]age.Identity, error)) (*AgeSealer, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one age recipient is required")
+	}
+
+	parsed := make([]age.Recipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", recipient, err)
+		}
+		parsed = append(parsed, r)
+	}
+
+	return &AgeSealer{recipients: parsed, identities: onceIdentities(identities)}, nil
+}
+
+// LoadAgeIdentities returns a function reading the age identities from a key file
+// as written by age-keygen
+func LoadAgeIdentities(path string) func() ([]age.Identity, error) {
+	return func() ([]age.Identity, error) {
+		if path == "" {
+			return nil, fmt.Errorf("an age identity file is required to decrypt the backup")
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open age identity file: %w", err)
+		}
+		defer file.Close()
+
+		identities, err := age.ParseIdentities(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity file %s: %w", path, err)
+		}
+		return identities, nil
+	}
+}
+
+// Seal encrypts a value to the recipients
+func (s *AgeSealer) Seal(plaintext string) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, s.recipients...)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	return formatSealed(schemeAge, buf.Bytes()), nil
+}
+
+// Open decrypts a sealed value with the identities
+func (s *AgeSealer) Open(value string) (string, error) {
+	raw, sealed, err := open(value, schemeAge)
+	if err != nil || !sealed {
+		return value, err
+	}
+
+	identities, err := s.identities()
+	if err != nil {
+		return "", err
+	}
+	r, err := age.Decrypt(bytes.NewReader(raw), identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// PassphraseSealer seals values with XChaCha20-Poly1305 using a key derived from a
+// passphrase with scrypt. Every value carries its salt; the values written by one
+// sealer share a salt, so the key is derived once per salt rather than once per value.
+type PassphraseSealer struct {
+	passphrase func() (string, error)
+
+	mu   sync.Mutex
+	salt []byte
+	keys map[string][]byte
+}
+
+// NewPassphraseSealer creates a sealer deriving its key from the passphrase returned by
+// passphrase, which is called at most once
+func NewPassphraseSealer(passphrase func() (string, error)) *PassphraseSealer {
+	return &PassphraseSealer{
+		passphrase: oncePassphrase(passphrase),
+		keys:       make(map[string][]byte),
+	}
+}
+
+// Seal encrypts a value with the passphrase-derived key
+func (s *PassphraseSealer) Seal(plaintext string) (string, error) {
+	s.mu.Lock()
+	if s.salt == nil {
+		s.salt = make([]byte, scryptSaltLen)
+		if _, err := rand.Read(s.salt); err != nil {
+			s.mu.Unlock()
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	salt := s.salt
+	s.mu.Unlock()
+
+	aead, err := s.aead(salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := append(append([]byte{}, salt...), nonce...)
+	data = aead.Seal(data, nonce, []byte(plaintext), nil)
+	return formatSealed(schemeScrypt, data), nil
+}
+
+// Open decrypts a sealed value with the passphrase-derived key
+func (s *PassphraseSealer) Open(value string) (string, error) {
+	raw, sealed, err := open(value, schemeScrypt)
+	if err != nil || !sealed {
+		return value, err
+	}
+
+	if len(raw) < scryptSaltLen+chacha20poly1305.NonceSizeX {
+		return "", fmt.Errorf("invalid sealed value: too short")
+	}
+	salt, rest := raw[:scryptSaltLen], raw[scryptSaltLen:]
+	nonce, ciphertext := rest[:chacha20poly1305.NonceSizeX], rest[chacha20poly1305.NonceSizeX:]
+
+	aead, err := s.aead(salt)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: wrong passphrase or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+// aead returns the cipher for the key derived from the passphrase and salt
+func (s *PassphraseSealer) aead(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[string(salt)]
+	if !ok {
+		key, err = scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		s.keys[string(salt)] = key
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+func onceIdentities(load func() ([]age.Identity, error)) func() ([]age.Identity, error) {
+	var (
+		once       sync.Once
+		identities []age.Identity
+		err        error
+	)
+	return func() ([]age.Identity, error) {
+		once.Do(func() { identities, err = load() })
+		return identities, err
+	}
+}
+
+func oncePassphrase(read func() (string, error)) func() (string, error) {
+	var (
+		once       sync.Once
+		passphrase string
+		err        error
+	)
+	return func() (string, error) {
+		once.Do(func() {
+			passphrase, err = read()
+			if err == nil && passphrase == "" {
+				err = fmt.Errorf("encryption passphrase cannot be empty")
+			}
+		})
+		return passphrase, err
+	}
+}
@@ -0,0 +1,102 @@
+package crypt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeSealer(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	sealer, err := NewAgeSealer([]string{identity.Recipient().String()}, func() ([]age.Identity, error) {
+		return []age.Identity{identity}, nil
+	})
+	require.NoError(t, err)
+
+	sealed, err := sealer.Seal("sk-123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "ENC[age,"))
+	assert.NotContains(t, sealed, "sk-123")
+	assert.True(t, IsSealed(sealed))
+
+	opened, err := sealer.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", opened)
+
+	// Values written before encryption was enabled are read as is
+	opened, err = sealer.Open("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", opened)
+}
+
+func TestAgeSealerWithoutIdentity(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	// Sealing only needs the recipient, opening needs the identity
+	sealer, err := NewAgeSealer([]string{identity.Recipient().String()}, LoadAgeIdentities(""))
+	require.NoError(t, err)
+
+	sealed, err := sealer.Seal("sk-123")
+	require.NoError(t, err)
+
+	_, err = sealer.Open(sealed)
+	assert.EqualError(t, err, "an age identity file is required to decrypt the backup")
+
+	_, err = NewAgeSealer([]string{"not-a-recipient"}, nil)
+	assert.ErrorContains(t, err, `invalid age recipient "not-a-recipient"`)
+}
+
+func TestPassphraseSealer(t *testing.T) {
+	calls := 0
+	sealer := NewPassphraseSealer(func() (string, error) {
+		calls++
+		return "correct horse", nil
+	})
+
+	first, err := sealer.Seal("sk-123")
+	require.NoError(t, err)
+	second, err := sealer.Seal("sk-123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(first, "ENC[scrypt,"))
+	assert.NotEqual(t, first, second)
+
+	opened, err := sealer.Open(first)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", opened)
+
+	// A fresh sealer with the same passphrase opens the values
+	opened, err = NewPassphraseSealer(func() (string, error) { return "correct horse", nil }).Open(second)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", opened)
+	assert.Equal(t, 1, calls)
+
+	_, err = NewPassphraseSealer(func() (string, error) { return "wrong", nil }).Open(first)
+	assert.EqualError(t, err, "failed to decrypt value: wrong passphrase or corrupted value")
+
+	_, err = NewPassphraseSealer(func() (string, error) { return "", nil }).Seal("x")
+	assert.EqualError(t, err, "encryption passphrase cannot be empty")
+
+	promptErr := errors.New("no terminal")
+	_, err = NewPassphraseSealer(func() (string, error) { return "", promptErr }).Open(first)
+	assert.ErrorIs(t, err, promptErr)
+}
+
+func TestOpenWithOtherScheme(t *testing.T) {
+	sealed, err := NewPassphraseSealer(func() (string, error) { return "pass", nil }).Seal("x")
+	require.NoError(t, err)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	sealer, err := NewAgeSealer([]string{identity.Recipient().String()}, nil)
+	require.NoError(t, err)
+
+	_, err = sealer.Open(sealed)
+	assert.EqualError(t, err, "value is sealed with scrypt, but age encryption is configured")
+}
@@ -0,0 +1,187 @@
+// Package envfile reads the files secrets are bulk-loaded from (dotenv files and
+// flat JSON objects mapping names to string values) and writes secrets back out
+// as dotenv, JSON, YAML or shell exports.
+package envfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseDotenv parses KEY=VALUE lines. Blank lines, '#' comments and an optional
+// "export " prefix are ignored. Values may be single quoted (taken literally) or
+// double quoted (\n, \t, \" and \\ escapes are expanded); unquoted values end at " #".
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	entries := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, rawValue, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		value, err := parseValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		entries[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return raw[1 : end+1], nil
+
+	case '"':
+		var value strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch c := raw[i]; c {
+			case '"':
+				return value.String(), nil
+			case '\\':
+				if i+1 == len(raw) {
+					return "", fmt.Errorf("unterminated double quote")
+				}
+				i++
+				switch raw[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 't':
+					value.WriteByte('\t')
+				case 'r':
+					value.WriteByte('\r')
+				default:
+					value.WriteByte(raw[i])
+				}
+			default:
+				value.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// ParseJSON parses a JSON object whose values are all strings
+func ParseJSON(r io.Reader) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	entries := make(map[string]string, len(raw))
+	for name, value := range raw {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %s is not a string", name)
+		}
+		entries[name] = str
+	}
+	return entries, nil
+}
+
+// Output formats supported by Write
+const (
+	FormatDotenv = "dotenv"
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	FormatShell  = "shell"
+)
+
+// ValidateFormat checks that format is one of the output formats supported by Write
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatDotenv, FormatJSON, FormatYAML, FormatShell:
+		return nil
+	}
+	return fmt.Errorf("invalid format: %s (must be dotenv, json, yaml or shell)", format)
+}
+
+// Write writes the entries sorted by name in the given format. Dotenv output
+// is read back unchanged by ParseDotenv; shell output can be sourced by sh and bash.
+func Write(w io.Writer, format string, entries map[string]string) error {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch format {
+	case FormatDotenv:
+		for _, name := range names {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", name, dotenvQuote(entries[name])); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatShell:
+		for _, name := range names {
+			if _, err := fmt.Fprintf(w, "export %s=%s\n", name, shellQuote(entries[name])); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(entries); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	return ValidateFormat(format)
+}
+
+// dotenvQuote double quotes a value, escaping what ParseDotenv expands
+func dotenvQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// shellQuote single quotes a value for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,120 @@
+package envfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	input := `# database settings
+DATABASE_URL=postgres://localhost/db
+export API_KEY = sk-123 # inline comment
+
+SINGLE='literal $HOME \n'
+DOUBLE="line1\nline2 \"quoted\""
+HASH=abc#def
+EMPTY=
+`
+
+	entries, err := ParseDotenv(strings.NewReader(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"DATABASE_URL": "postgres://localhost/db",
+		"API_KEY":      "sk-123",
+		"SINGLE":       `literal $HOME \n`,
+		"DOUBLE":       "line1\nline2 \"quoted\"",
+		"HASH":         "abc#def",
+		"EMPTY":        "",
+	}, entries)
+}
+
+func TestParseDotenvInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "missing equals", input: "API_KEY\n", wantErr: "line 1: expected KEY=VALUE"},
+		{name: "missing name", input: "=value\n", wantErr: "line 1: expected KEY=VALUE"},
+		{name: "unterminated single quote", input: "# comment\nA='abc\n", wantErr: "line 2: unterminated single quote"},
+		{name: "unterminated double quote", input: `A="abc`, wantErr: "line 1: unterminated double quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotenv(strings.NewReader(tt.input))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	entries, err := ParseJSON(strings.NewReader(`{"API_KEY":"sk-123","TOKEN":"abc"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "sk-123", "TOKEN": "abc"}, entries)
+
+	_, err = ParseJSON(strings.NewReader(`{"PORT":8080}`))
+	assert.EqualError(t, err, "value of PORT is not a string")
+
+	_, err = ParseJSON(strings.NewReader(`not json`))
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	entries := map[string]string{
+		"TOKEN":   "it's \"quoted\"",
+		"API_KEY": "line1\nline2",
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   FormatDotenv,
+			expected: "API_KEY=\"line1\\nline2\"\nTOKEN=\"it's \\\"quoted\\\"\"\n",
+		},
+		{
+			format:   FormatShell,
+			expected: "export API_KEY='line1\nline2'\nexport TOKEN='it'\\''s \"quoted\"'\n",
+		},
+		{
+			format:   FormatJSON,
+			expected: "{\n  \"API_KEY\": \"line1\\nline2\",\n  \"TOKEN\": \"it's \\\"quoted\\\"\"\n}\n",
+		},
+		{
+			format:   FormatYAML,
+			expected: "API_KEY: |-\n  line1\n  line2\nTOKEN: it's \"quoted\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf strings.Builder
+			require.NoError(t, Write(&buf, tt.format, entries))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	assert.EqualError(t, Write(&strings.Builder{}, "xml", entries), "invalid format: xml (must be dotenv, json, yaml or shell)")
+}
+
+func TestWriteDotenvRoundTrip(t *testing.T) {
+	entries := map[string]string{
+		"PATH_LIKE": `C:\temp\new`,
+		"PEM":       "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+		"QUOTES":    `'single' "double" # not a comment`,
+		"EMPTY":     "",
+	}
+
+	var buf strings.Builder
+	require.NoError(t, Write(&buf, FormatDotenv, entries))
+
+	parsed, err := ParseDotenv(strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, entries, parsed)
+}
@@ -3,10 +3,18 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Client struct {
@@ -95,14 +103,68 @@ func (c *Client) GetSecret(ctx context.Context, name string) (string, error) {
 	return string(result.Payload.Data), nil
 }
 
+// ListSecretVersions returns the enabled versions of the secret, newest first.
+// The newest version is the one read by GetSecret.
+func (c *Client) ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error) {
+	it := c.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", c.projectID, name),
+		Filter: "state:ENABLED",
+	})
+
+	var versions []bundle.Version
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secret versions: %w", err)
+		}
+		versions = append(versions, bundle.Version{
+			ID:      path.Base(version.GetName()),
+			Created: version.GetCreateTime().AsTime(),
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Created.After(versions[j].Created) })
+	if len(versions) > 0 {
+		versions[0].Current = true
+	}
+	return versions, nil
+}
+
+// GetSecretAtVersion returns the value of the given version of the secret
+func (c *Client) GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error) {
+	result, err := c.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", c.projectID, name, versionID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to access secret version: %w", err)
+	}
+
+	return string(result.Payload.Data), nil
+}
+
+// GetLastChangedDate returns the creation time of the latest secret version
+func (c *Client) GetLastChangedDate(ctx context.Context, name string) (time.Time, error) {
+	version, err := c.client.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", c.projectID, name),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get secret version: %w", err)
+	}
+
+	return version.GetCreateTime().AsTime(), nil
+}
+
 func (c *Client) Close() error {
 	return c.client.Close()
 }
 
 func isSecretExistsError(err error) bool {
 	// Check if error indicates that secret already exists
-	if err != nil {
-		return err.Error() == "rpc error: code = AlreadyExists desc = Secret [projects/*/secrets/*] already exists."
+	if err == nil {
+		return false
 	}
-	return false
+	return status.Code(err) == codes.AlreadyExists || strings.Contains(err.Error(), "code = AlreadyExists")
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package gcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/tom-023/ghsecrets/internal/bundle"
+)
+
+// SecretClient defines the interface for secret operations
+type SecretClient interface {
+	CreateOrUpdateSecret(ctx context.Context, name, value string) error
+	GetSecret(ctx context.Context, name string) (string, error)
+	GetLastChangedDate(ctx context.Context, name string) (time.Time, error)
+}
+
+// HistoryClient is implemented by clients that can read earlier versions of a secret
+type HistoryClient interface {
+	ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error)
+	GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error)
+}
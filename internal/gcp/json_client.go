@@ -0,0 +1,230 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JSONClient wraps the GCP client to store multiple key-value pairs in a single secret.
+// Every write adds a new version of the secret containing the whole JSON payload.
+// Keys are read and written in one section of the bundle (see package bundle).
+type JSONClient struct {
+	client     SecretClient
+	secretName string
+	section    string
+	// versionID pins reads to an earlier version of the secret; such a client is read-only
+	versionID string
+}
+
+// NewJSONClient creates a new client that stores secrets as JSON
+func NewJSONClient(client SecretClient, secretName string) *JSONClient {
+	return &JSONClient{
+		client:     client,
+		secretName: secretName,
+		section:    bundle.RootSection,
+	}
+}
+
+// Section returns a client that reads and writes the given section of the same secret
+func (j *JSONClient) Section(section string) *JSONClient {
+	return &JSONClient{
+		client:     j.client,
+		secretName: j.secretName,
+		section:    section,
+		versionID:  j.versionID,
+	}
+}
+
+// AtVersion returns a read-only client for an earlier version of the secret,
+// as listed by Versions
+func (j *JSONClient) AtVersion(versionID string) *JSONClient {
+	return &JSONClient{
+		client:     j.client,
+		secretName: j.secretName,
+		section:    j.section,
+		versionID:  versionID,
+	}
+}
+
+// Versions lists the enabled versions of the secret, newest first
+func (j *JSONClient) Versions(ctx context.Context) ([]bundle.Version, error) {
+	history, ok := j.client.(HistoryClient)
+	if !ok {
+		return nil, fmt.Errorf("version history is not available for secret '%s'", j.secretName)
+	}
+
+	versions, err := history.ListSecretVersions(ctx, j.secretName)
+	if err != nil {
+		return nil, j.wrapGetSecretError(err)
+	}
+	return versions, nil
+}
+
+// AddOrUpdateKey adds or updates a key-value pair in the JSON secret.
+// Unlike AWS, the secret is created on first write if it does not exist yet.
+func (j *JSONClient) AddOrUpdateKey(ctx context.Context, key, value string) error {
+	return j.AddOrUpdateKeys(ctx, map[string]string{key: value})
+}
+
+// AddOrUpdateKeys adds or updates several key-value pairs as a single new version of the secret
+func (j *JSONClient) AddOrUpdateKeys(ctx context.Context, keys map[string]string) error {
+	if err := j.checkWritable(); err != nil {
+		return err
+	}
+
+	doc, err := j.load(ctx)
+	if err != nil {
+		if !isSecretNotFoundError(err) {
+			return j.wrapGetSecretError(err)
+		}
+		doc = bundle.New()
+	}
+
+	// Add or update the keys
+	for key, value := range keys {
+		doc.Set(j.section, key, value)
+	}
+
+	return j.save(ctx, doc)
+}
+
+// RemoveKey removes a key from the JSON secret by adding a version without it
+func (j *JSONClient) RemoveKey(ctx context.Context, key string) error {
+	if err := j.checkWritable(); err != nil {
+		return err
+	}
+
+	doc, err := j.load(ctx)
+	if err != nil {
+		return j.wrapGetSecretError(err)
+	}
+
+	if !doc.Delete(j.section, key) {
+		return fmt.Errorf("key %s not found in secret", key)
+	}
+
+	return j.save(ctx, doc)
+}
+
+// GetKey retrieves a specific key from the JSON secret
+func (j *JSONClient) GetKey(ctx context.Context, key string) (string, error) {
+	doc, err := j.load(ctx)
+	if err != nil {
+		return "", j.wrapGetSecretError(err)
+	}
+
+	value, exists := doc.Get(j.section, key)
+	if !exists {
+		return "", fmt.Errorf("key %s not found in secret", key)
+	}
+
+	return value, nil
+}
+
+// GetAllKeys retrieves all key-value pairs from the JSON secret
+func (j *JSONClient) GetAllKeys(ctx context.Context) (map[string]string, error) {
+	doc, err := j.load(ctx)
+	if err != nil {
+		return nil, j.wrapGetSecretError(err)
+	}
+	return doc.Keys(j.section), nil
+}
+
+// LastUpdated returns the time the JSON secret was last written
+func (j *JSONClient) LastUpdated(ctx context.Context) (time.Time, error) {
+	changed, err := j.client.GetLastChangedDate(ctx, j.secretName)
+	if err != nil {
+		return time.Time{}, j.wrapGetSecretError(err)
+	}
+	return changed, nil
+}
+
+// checkWritable refuses writes through a client pinned to an earlier version
+func (j *JSONClient) checkWritable() error {
+	if j.versionID != "" {
+		return fmt.Errorf("version %s of secret '%s' is read-only", j.versionID, j.secretName)
+	}
+	return nil
+}
+
+// load reads the latest (or pinned) version of the secret and parses it as a bundle
+func (j *JSONClient) load(ctx context.Context) (*bundle.Document, error) {
+	existingJSON, err := j.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := bundle.Parse(existingJSON)
+	if err != nil {
+		return nil, fmt.Errorf("secret '%s' exists but is not in valid JSON format: %w", j.secretName, err)
+	}
+
+	return doc, nil
+}
+
+// read returns the latest version of the secret, or the pinned version
+func (j *JSONClient) read(ctx context.Context) (string, error) {
+	if j.versionID == "" {
+		return j.client.GetSecret(ctx, j.secretName)
+	}
+
+	history, ok := j.client.(HistoryClient)
+	if !ok {
+		return "", fmt.Errorf("version history is not available for secret '%s'", j.secretName)
+	}
+	return history.GetSecretAtVersion(ctx, j.secretName, j.versionID)
+}
+
+// save adds a new secret version containing the whole bundle
+func (j *JSONClient) save(ctx context.Context, doc *bundle.Document) error {
+	updatedJSON, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return j.client.CreateOrUpdateSecret(ctx, j.secretName, updatedJSON)
+}
+
+// wrapGetSecretError wraps GetSecret errors with more meaningful messages
+func (j *JSONClient) wrapGetSecretError(err error) error {
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return fmt.Errorf("GCP authentication error: %w. Please check your GCP credentials or run 'gcloud auth application-default login'", err)
+	case codes.NotFound:
+		if j.versionID != "" {
+			return fmt.Errorf("version %s of GCP Secret Manager secret '%s' not found", j.versionID, j.secretName)
+		}
+		return &secretNotFoundError{secretName: j.secretName}
+	case codes.Unknown:
+		// Errors that did not come from the API (e.g. invalid JSON) are returned as-is
+		return err
+	}
+
+	return fmt.Errorf("failed to access GCP Secret Manager: %w", err)
+}
+
+// secretNotFoundError is returned when the backup secret does not exist yet
+type secretNotFoundError struct {
+	secretName string
+}
+
+func (e *secretNotFoundError) Error() string {
+	return fmt.Sprintf("GCP Secret Manager secret '%s' not found. Push a secret with '-b gcp' first or specify a different secret_name in config", e.secretName)
+}
+
+// IsSecretNotFound reports whether err means the backup secret does not exist yet.
+// The first push creates it.
+func IsSecretNotFound(err error) bool {
+	var notFound *secretNotFoundError
+	return errors.As(err, &notFound)
+}
+
+func isSecretNotFoundError(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
@@ -0,0 +1,274 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJSONClient_AddOrUpdateKey(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	// Test adding first key (the secret is created on first write)
+	err := jsonClient.AddOrUpdateKey(ctx, "key1", "value1")
+	require.NoError(t, err)
+
+	// Verify the secret contains the key
+	secretJSON, err := mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+
+	var data map[string]string
+	err = json.Unmarshal([]byte(secretJSON), &data)
+	require.NoError(t, err)
+	assert.Equal(t, "value1", data["key1"])
+
+	// Test adding second key
+	err = jsonClient.AddOrUpdateKey(ctx, "key2", "value2")
+	require.NoError(t, err)
+
+	// Test updating existing key
+	err = jsonClient.AddOrUpdateKey(ctx, "key1", "updated-value1")
+	require.NoError(t, err)
+
+	// Verify both keys exist and the first one was updated
+	secretJSON, err = mockClient.GetSecret(ctx, "test-secret")
+	require.NoError(t, err)
+
+	err = json.Unmarshal([]byte(secretJSON), &data)
+	require.NoError(t, err)
+	assert.Equal(t, "updated-value1", data["key1"])
+	assert.Equal(t, "value2", data["key2"])
+}
+
+func TestJSONClient_GetKey(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	// Setup test data
+	testData := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}
+	jsonData, _ := json.Marshal(testData)
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", string(jsonData))
+
+	// Test getting existing key
+	value, err := jsonClient.GetKey(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	// Test getting non-existent key
+	_, err = jsonClient.GetKey(ctx, "non-existent")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key non-existent not found")
+}
+
+func TestJSONClient_GetAllKeys(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	// Setup test data
+	testData := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+		"key3": "value3",
+	}
+	jsonData, _ := json.Marshal(testData)
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", string(jsonData))
+
+	// Test getting all keys
+	allKeys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testData, allKeys)
+}
+
+func TestJSONClient_NonExistentSecret(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "non-existent-secret")
+
+	// Reading a secret that was never written should fail with a clear message
+	_, err := jsonClient.GetAllKeys(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GCP Secret Manager secret 'non-existent-secret' not found")
+	assert.True(t, IsSecretNotFound(err))
+}
+
+func TestJSONClient_InvalidJSONFormat(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "invalid-json-secret")
+
+	// Create a secret with invalid JSON
+	mockClient.CreateOrUpdateSecret(ctx, "invalid-json-secret", "not-a-json-string")
+
+	// Test adding key to secret with invalid JSON
+	err := jsonClient.AddOrUpdateKey(ctx, "key1", "value1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secret 'invalid-json-secret' exists but is not in valid JSON format")
+}
+
+func TestJSONClient_AuthenticationErrors(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	tests := []struct {
+		name string
+		code codes.Code
+	}{
+		{name: "Unauthenticated", code: codes.Unauthenticated},
+		{name: "Permission denied", code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.SetError("GetSecret", status.Error(tt.code, "request failed"))
+
+			err := jsonClient.AddOrUpdateKey(ctx, "key1", "value1")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "GCP authentication error")
+			assert.Contains(t, err.Error(), "gcloud auth application-default login")
+
+			_, err = jsonClient.GetAllKeys(ctx)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "GCP authentication error")
+
+			// Clear error for next test
+			mockClient.SetError("GetSecret", nil)
+		})
+	}
+}
+
+func TestJSONClient_LastUpdated(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	before := time.Now()
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", "{}")
+
+	changed, err := jsonClient.LastUpdated(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed.Before(before))
+
+	// A missing secret is reported the same way as for reads
+	_, err = NewJSONClient(mockClient, "missing-secret").LastUpdated(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GCP Secret Manager secret 'missing-secret' not found")
+}
+
+func TestJSONClient_RemoveKey(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+
+	// Setup test data
+	jsonData, _ := json.Marshal(map[string]string{"key1": "value1", "key2": "value2"})
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", string(jsonData))
+
+	// Test removing an existing key
+	err := jsonClient.RemoveKey(ctx, "key1")
+	require.NoError(t, err)
+
+	allKeys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key2": "value2"}, allKeys)
+
+	// Test removing a non-existent key
+	err = jsonClient.RemoveKey(ctx, "key1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "key key1 not found")
+}
+
+func TestJSONClient_Section(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	envClient := jsonClient.Section(bundle.EnvironmentSection("production"))
+
+	// Setup a bundle in the original flat layout
+	mockClient.CreateOrUpdateSecret(ctx, "test-secret", `{"API_KEY":"repo-value"}`)
+
+	// Environment keys are stored apart from repository keys
+	err := envClient.AddOrUpdateKey(ctx, "API_KEY", "prod-value")
+	require.NoError(t, err)
+
+	repoKeys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "repo-value"}, repoKeys)
+
+	envKeys, err := envClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "prod-value"}, envKeys)
+
+	// Removing the environment key leaves the repository key untouched
+	require.NoError(t, envClient.RemoveKey(ctx, "API_KEY"))
+	value, err := jsonClient.GetKey(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "repo-value", value)
+}
+
+// countingClient counts the versions added through the wrapped client
+type countingClient struct {
+	SecretClient
+	writes int
+}
+
+func (c *countingClient) CreateOrUpdateSecret(ctx context.Context, name, value string) error {
+	c.writes++
+	return c.SecretClient.CreateOrUpdateSecret(ctx, name, value)
+}
+
+func TestJSONClient_AddOrUpdateKeys(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingClient{SecretClient: NewMockClient("test-project")}
+	jsonClient := NewJSONClient(counting, "test-secret")
+
+	// The secret doesn't exist yet and is created with all keys in one version
+	err := jsonClient.AddOrUpdateKeys(ctx, map[string]string{"API_KEY": "value1", "TOKEN": "value2"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, counting.writes)
+
+	keys, err := jsonClient.GetAllKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "value1", "TOKEN": "value2"}, keys)
+}
+
+func TestJSONClient_Versions(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-project")
+
+	jsonClient := NewJSONClient(mockClient, "test-secret")
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "API_KEY", "good"))
+	require.NoError(t, jsonClient.AddOrUpdateKey(ctx, "API_KEY", "bad"))
+
+	versions, err := jsonClient.Versions(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "2", versions[0].ID)
+	assert.True(t, versions[0].Current)
+
+	// An earlier version is read through a pinned, read-only client
+	previous := jsonClient.AtVersion("1")
+	value, err := previous.GetKey(ctx, "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "good", value)
+
+	err = previous.RemoveKey(ctx, "API_KEY")
+	assert.EqualError(t, err, "version 1 of secret 'test-secret' is read-only")
+
+	_, err = jsonClient.AtVersion("9").GetAllKeys(ctx)
+	assert.EqualError(t, err, "version 9 of GCP Secret Manager secret 'test-secret' not found")
+}
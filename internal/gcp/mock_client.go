@@ -2,14 +2,27 @@ package gcp
 
 import (
 	"context"
-	"fmt"
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/tom-023/ghsecrets/internal/bundle"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// mockVersion is a stored version of a mock secret
+type mockVersion struct {
+	value   string
+	created time.Time
+}
+
 // MockClient is a mock implementation of GCP Secret Manager client for testing
 type MockClient struct {
 	mu        sync.Mutex
 	secrets   map[string]string
+	history   map[string][]mockVersion
+	changed   map[string]time.Time
 	errors    map[string]error
 	projectID string
 }
@@ -19,6 +32,8 @@ func NewMockClient(projectID string) *MockClient {
 	return &MockClient{
 		projectID: projectID,
 		secrets:   make(map[string]string),
+		history:   make(map[string][]mockVersion),
+		changed:   make(map[string]time.Time),
 		errors:    make(map[string]error),
 	}
 }
@@ -42,14 +57,72 @@ func (m *MockClient) CreateOrUpdateSecret(ctx context.Context, name, value strin
 	// Simulate secret already exists error if configured
 	if _, exists := m.secrets[name]; exists && m.errors["CreateSecret"] != nil {
 		// Just update the value
-		m.secrets[name] = value
+		m.addVersion(name, value)
 		return nil
 	}
 
-	m.secrets[name] = value
+	m.addVersion(name, value)
 	return nil
 }
 
+// addVersion stores value as the latest version of the secret. The caller must hold m.mu.
+func (m *MockClient) addVersion(name, value string) {
+	m.secrets[name] = value
+	m.changed[name] = time.Now()
+	m.history[name] = append(m.history[name], mockVersion{value: value, created: m.changed[name]})
+}
+
+// ListSecretVersions mocks the ListSecretVersions method. Versions are numbered from 1 like in GCP.
+func (m *MockClient) ListSecretVersions(ctx context.Context, name string) ([]bundle.Version, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["ListSecretVersions"]; err != nil {
+		return nil, err
+	}
+
+	history, exists := m.history[name]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "secret not found: %s", name)
+	}
+
+	versions := make([]bundle.Version, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		versions = append(versions, bundle.Version{
+			ID:      strconv.Itoa(i + 1),
+			Created: history[i].created,
+			Current: i == len(history)-1,
+		})
+	}
+	return versions, nil
+}
+
+// GetSecretAtVersion mocks the GetSecretAtVersion method
+func (m *MockClient) GetSecretAtVersion(ctx context.Context, name, versionID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["GetSecretAtVersion"]; err != nil {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(versionID)
+	if err != nil || n < 1 || n > len(m.history[name]) {
+		return "", status.Errorf(codes.NotFound, "secret version not found: %s/versions/%s", name, versionID)
+	}
+	return m.history[name][n-1].value, nil
+}
+
+// SetVersionCreated changes the creation time of a stored version
+func (m *MockClient) SetVersionCreated(name, versionID string, created time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n, err := strconv.Atoi(versionID); err == nil && n >= 1 && n <= len(m.history[name]) {
+		m.history[name][n-1].created = created
+	}
+}
+
 // GetSecret mocks the GetSecret method
 func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error) {
 	m.mu.Lock()
@@ -61,12 +134,30 @@ func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error)
 
 	value, exists := m.secrets[name]
 	if !exists {
-		return "", fmt.Errorf("secret not found: %s", name)
+		// Return a gRPC NotFound status for consistency with the real client
+		return "", status.Errorf(codes.NotFound, "secret not found: %s", name)
 	}
 
 	return value, nil
 }
 
+// GetLastChangedDate mocks the GetLastChangedDate method
+func (m *MockClient) GetLastChangedDate(ctx context.Context, name string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["GetLastChangedDate"]; err != nil {
+		return time.Time{}, err
+	}
+
+	changed, exists := m.changed[name]
+	if !exists {
+		return time.Time{}, status.Errorf(codes.NotFound, "secret not found: %s", name)
+	}
+
+	return changed, nil
+}
+
 // Close mocks the Close method
 func (m *MockClient) Close() error {
 	return nil
@@ -0,0 +1,95 @@
+package github
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/tom-023/ghsecrets/internal/auth"
+	"golang.org/x/oauth2"
+)
+
+// installationTokenRefreshMargin renews installation tokens this long before they
+// expire, so that requests of long runs never carry an expired token
+const installationTokenRefreshMargin = 5 * time.Minute
+
+// AppAuth holds the credentials of a GitHub App
+type AppAuth struct {
+	AppID int64
+	// InstallationID is the installation to act as. 0 discovers the installation
+	// on the repository or organization being managed.
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+}
+
+// appTransport authenticates requests as the GitHub App itself with a freshly signed JWT
+type appTransport struct {
+	appID int64
+	key   *rsa.PrivateKey
+	base  http.RoundTripper
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := auth.SignAppJWT(t.appID, t.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(authenticated)
+}
+
+// installationTokenSource exchanges app JWTs for installation access tokens
+type installationTokenSource struct {
+	ctx            context.Context
+	client         *github.Client
+	installationID int64
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	token, _, err := s.client.Apps.CreateInstallationToken(s.ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "Bearer",
+		Expiry:      token.GetExpiresAt(),
+	}, nil
+}
+
+// NewAppTokenSource returns a token source of installation tokens of the GitHub App,
+// for use as ClientOptions.TokenSource. Tokens are cached and renewed before they expire.
+// Without an installation ID, the installation is looked up on owner/repo, or on the
+// organization owner when repo is empty.
+func NewAppTokenSource(ctx context.Context, app AppAuth, owner, repo string, opts ClientOptions) (oauth2.TokenSource, error) {
+	httpClient := &http.Client{
+		Transport: &appTransport{appID: app.AppID, key: app.PrivateKey, base: http.DefaultTransport},
+	}
+	appClient, err := newGitHubAPIClient(httpClient, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	installationID := app.InstallationID
+	if installationID == 0 {
+		var installation *github.Installation
+		if repo == "" {
+			installation, _, err = appClient.Apps.FindOrganizationInstallation(ctx, owner)
+		} else {
+			installation, _, err = appClient.Apps.FindRepositoryInstallation(ctx, owner, repo)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find GitHub App installation for %s: %w", owner, err)
+		}
+		installationID = installation.GetID()
+	}
+
+	source := &installationTokenSource{ctx: ctx, client: appClient, installationID: installationID}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, source, installationTokenRefreshMargin), nil
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAppTestServer serves installation discovery and token creation on a GHES-style API path.
+// Every created token expires after lifetime.
+func newAppTestServer(t *testing.T, lifetime time.Duration, created *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		assert.Len(t, strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), "."), 3)
+		fmt.Fprint(w, `{"id":99}`)
+	})
+	mux.HandleFunc("/api/v3/app/installations/99/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		n := atomic.AddInt32(created, 1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":"%s"}`, n, time.Now().Add(lifetime).UTC().Format(time.RFC3339))
+	})
+	mux.HandleFunc("/api/v3/repos/owner/repo/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ghs_"))
+		fmt.Fprint(w, `{"total_count":0,"secrets":[]}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestAppTokenSourceDiscoversInstallation(t *testing.T) {
+	var created int32
+	server := newAppTestServer(t, time.Hour, &created)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	opts := ClientOptions{BaseURL: server.URL + "/"}
+	source, err := NewAppTokenSource(context.Background(), AppAuth{AppID: 1, PrivateKey: key}, "owner", "repo", opts)
+	require.NoError(t, err)
+
+	opts.TokenSource = source
+	client, err := NewClientWithOptions("", "owner", "repo", opts)
+	require.NoError(t, err)
+
+	_, err = client.ListSecrets(context.Background())
+	require.NoError(t, err)
+	_, err = client.ListSecrets(context.Background())
+	require.NoError(t, err)
+
+	// The installation token is reused while it is valid
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+}
+
+func TestAppTokenSourceRefreshesExpiringTokens(t *testing.T) {
+	var created int32
+	server := newAppTestServer(t, time.Minute, &created)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	app := AppAuth{AppID: 1, InstallationID: 99, PrivateKey: key}
+	source, err := NewAppTokenSource(context.Background(), app, "owner", "repo", ClientOptions{BaseURL: server.URL + "/"})
+	require.NoError(t, err)
+
+	first, err := source.Token()
+	require.NoError(t, err)
+	second, err := source.Token()
+	require.NoError(t, err)
+
+	// Tokens expiring within the refresh margin are renewed
+	assert.NotEqual(t, first.AccessToken, second.AccessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&created))
+}
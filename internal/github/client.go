@@ -5,9 +5,14 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	"golang.org/x/crypto/nacl/box"
@@ -15,10 +20,62 @@ import (
 )
 
 type Client struct {
-	client *github.Client
-	owner  string
-	repo   string
-	token  string
+	client      *github.Client
+	owner       string
+	repo        string
+	token       string
+	org         string
+	environment string
+	store       Store
+	cache       *clientCache
+}
+
+// clientCache holds the lookups a client and its environment and store clones make once
+// per run, so that concurrent writes share them
+type clientCache struct {
+	keysMu     sync.Mutex
+	publicKeys map[string]*github.PublicKey
+
+	repoMu sync.Mutex
+	repoID int
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{publicKeys: make(map[string]*github.PublicKey)}
+}
+
+// errSecretNotFound is returned when GitHub reports that a secret does not exist
+var errSecretNotFound = errors.New("not found")
+
+// IsSecretNotFound reports whether err means the secret does not exist in GitHub
+func IsSecretNotFound(err error) bool {
+	return errors.Is(err, errSecretNotFound)
+}
+
+// ClientOptions contains options for creating a GitHub client
+type ClientOptions struct {
+	// BaseURL is the API URL of a GitHub Enterprise Server, for example
+	// https://github.example.com/api/v3/. Empty uses api.github.com.
+	BaseURL string
+	// UploadURL is the upload URL of a GitHub Enterprise Server.
+	// Empty derives it from BaseURL.
+	UploadURL string
+	// TokenSource provides the access tokens instead of a static token,
+	// for example the installation tokens of a GitHub App
+	TokenSource oauth2.TokenSource
+}
+
+// Hostname returns the host the options point at, as used by 'gh auth token --hostname'
+func (o ClientOptions) Hostname() string {
+	if o.BaseURL == "" {
+		return "github.com"
+	}
+
+	baseURL, err := url.Parse(o.BaseURL)
+	if err != nil {
+		return ""
+	}
+	return baseURL.Hostname()
 }
 
 func NewClient(token, owner, repo string) *Client {
@@ -27,17 +84,91 @@ func NewClient(token, owner, repo string) *Client {
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	client := github.NewClient(withRetries(tc))
+
+	return &Client{
+		client: client,
+		owner:  owner,
+		repo:   repo,
+		token:  token,
+		cache:  newClientCache(),
+	}
+}
+
+// NewClientWithOptions creates a repository client, for GitHub Enterprise Server when a base URL is given
+func NewClientWithOptions(token, owner, repo string, opts ClientOptions) (*Client, error) {
+	client, err := newAPIClient(token, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Client{
 		client: client,
 		owner:  owner,
 		repo:   repo,
 		token:  token,
+		cache:  newClientCache(),
+	}, nil
+}
+
+// newAPIClient creates the go-github client authenticated with the token, or with
+// opts.TokenSource when set
+func newAPIClient(token string, opts ClientOptions) (*github.Client, error) {
+	ts := opts.TokenSource
+	if ts == nil {
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
 	}
+	return newGitHubAPIClient(oauth2.NewClient(context.Background(), ts), opts)
 }
 
+// newGitHubAPIClient creates the go-github client on top of httpClient,
+// using the enterprise constructor when a base URL is given. Rate limited
+// and failed requests are retried.
+func newGitHubAPIClient(httpClient *http.Client, opts ClientOptions) (*github.Client, error) {
+	httpClient = withRetries(httpClient)
+	if opts.BaseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	uploadURL := opts.UploadURL
+	if uploadURL == "" {
+		// go-github appends api/uploads/ to the server root
+		uploadURL = strings.TrimSuffix(strings.TrimSuffix(opts.BaseURL, "/"), "/api/v3")
+	}
+
+	client, err := github.NewEnterpriseClient(opts.BaseURL, uploadURL, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub Enterprise URL: %w", err)
+	}
+	return client, nil
+}
+
+// WithEnvironment returns a client that targets the secrets of an Actions
+// environment of the repository instead of the repository-level secrets.
+// An empty name targets the repository-level secrets.
+func (c *Client) WithEnvironment(environment string) *Client {
+	clone := *c
+	clone.environment = environment
+	return &clone
+}
+
+// CreateOrUpdateSecret creates or updates a repository or environment secret.
+// On an organization client it keeps the visibility of an existing secret and
+// creates new secrets as private; use CreateOrUpdateOrgSecret to choose it.
 func (c *Client) CreateOrUpdateSecret(ctx context.Context, name, value string) error {
+	if c.org != "" {
+		settings, err := c.GetOrgSecretSettings(ctx, name)
+		if err != nil {
+			if !IsSecretNotFound(err) {
+				return err
+			}
+			settings = OrgSecretSettings{Visibility: VisibilityPrivate}
+		}
+		return c.CreateOrUpdateOrgSecret(ctx, name, value, settings)
+	}
+
 	publicKey, err := c.getPublicKey(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get public key: %w", err)
@@ -48,11 +179,23 @@ func (c *Client) CreateOrUpdateSecret(ctx context.Context, name, value string) e
 		return fmt.Errorf("failed to encrypt secret: %w", err)
 	}
 
-	_, err = c.client.Actions.CreateOrUpdateRepoSecret(ctx, c.owner, c.repo, &github.EncryptedSecret{
+	secret := &github.EncryptedSecret{
 		Name:           name,
 		KeyID:          publicKey.GetKeyID(),
 		EncryptedValue: encryptedValue,
-	})
+	}
+
+	if c.usesStoreAPI() {
+		_, err = c.putStoreSecret(ctx, secret)
+	} else if c.environment != "" {
+		repoID, idErr := c.getRepoID(ctx)
+		if idErr != nil {
+			return idErr
+		}
+		_, err = c.client.Actions.CreateOrUpdateEnvSecret(ctx, repoID, c.environment, secret)
+	} else {
+		_, err = c.client.Actions.CreateOrUpdateRepoSecret(ctx, c.owner, c.repo, secret)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create/update secret: %w", err)
 	}
@@ -60,7 +203,151 @@ func (c *Client) CreateOrUpdateSecret(ctx context.Context, name, value string) e
 	return nil
 }
 
+// DeleteSecret deletes a repository, environment or organization secret of the store
+func (c *Client) DeleteSecret(ctx context.Context, name string) error {
+	var resp *github.Response
+	var err error
+	if c.usesStoreAPI() {
+		resp, err = c.deleteStoreSecret(ctx, name)
+	} else if c.org != "" {
+		resp, err = c.client.Actions.DeleteOrgSecret(ctx, c.org, name)
+	} else if c.environment != "" {
+		repoID, idErr := c.getRepoID(ctx)
+		if idErr != nil {
+			return idErr
+		}
+		resp, err = c.client.Actions.DeleteEnvSecret(ctx, repoID, c.environment, name)
+	} else {
+		resp, err = c.client.Actions.DeleteRepoSecret(ctx, c.owner, c.repo, name)
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("secret %s %w", name, errSecretNotFound)
+		}
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateSecretName checks a name against GitHub's naming rules for secrets
+func ValidateSecretName(name string) error {
+	if name == "" {
+		return fmt.Errorf("secret name cannot be empty")
+	}
+	if strings.HasPrefix(strings.ToUpper(name), "GITHUB_") {
+		return fmt.Errorf("secret name %s must not start with GITHUB_", name)
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return fmt.Errorf("secret name %s must not start with a number", name)
+	}
+	for _, r := range name {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return fmt.Errorf("secret name %s may only contain letters, numbers and underscores", name)
+		}
+	}
+	return nil
+}
+
+// SecretInfo holds the metadata GitHub exposes for a secret (values are never readable)
+type SecretInfo struct {
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Visibility string    `json:"visibility,omitempty"`
+}
+
+// ListSecrets returns all repository, environment or organization secrets of the store, following pagination
+func (c *Client) ListSecrets(ctx context.Context) ([]SecretInfo, error) {
+	var repoID int
+	if c.environment != "" {
+		var err error
+		if repoID, err = c.getRepoID(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var secrets []SecretInfo
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var page *github.Secrets
+		var resp *github.Response
+		var err error
+		if c.usesStoreAPI() {
+			page, resp, err = c.listStoreSecrets(ctx, opts)
+		} else if c.org != "" {
+			page, resp, err = c.client.Actions.ListOrgSecrets(ctx, c.org, opts)
+		} else if c.environment != "" {
+			page, resp, err = c.client.Actions.ListEnvSecrets(ctx, repoID, c.environment, opts)
+		} else {
+			page, resp, err = c.client.Actions.ListRepoSecrets(ctx, c.owner, c.repo, opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+
+		for _, secret := range page.Secrets {
+			secrets = append(secrets, SecretInfo{
+				Name:       secret.Name,
+				CreatedAt:  secret.CreatedAt.Time,
+				UpdatedAt:  secret.UpdatedAt.Time,
+				Visibility: secret.Visibility,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return secrets, nil
+}
+
+// getPublicKey returns the public key of the store, environment or organization,
+// fetching it only once per run
 func (c *Client) getPublicKey(ctx context.Context) (*github.PublicKey, error) {
+	c.cache.keysMu.Lock()
+	defer c.cache.keysMu.Unlock()
+
+	target := fmt.Sprintf("%s/%s/%s", c.Store(), c.org, c.environment)
+	if publicKey, ok := c.cache.publicKeys[target]; ok {
+		return publicKey, nil
+	}
+
+	publicKey, err := c.fetchPublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.publicKeys[target] = publicKey
+	return publicKey, nil
+}
+
+func (c *Client) fetchPublicKey(ctx context.Context) (*github.PublicKey, error) {
+	if c.usesStoreAPI() {
+		return c.getStorePublicKey(ctx)
+	}
+
+	if c.org != "" {
+		publicKey, _, err := c.client.Actions.GetOrgPublicKey(ctx, c.org)
+		if err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}
+
+	if c.environment != "" {
+		repoID, err := c.getRepoID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, _, err := c.client.Actions.GetEnvPublicKey(ctx, repoID, c.environment)
+		if err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}
+
 	publicKey, _, err := c.client.Actions.GetRepoPublicKey(ctx, c.owner, c.repo)
 	if err != nil {
 		return nil, err
@@ -68,6 +355,24 @@ func (c *Client) getPublicKey(ctx context.Context) (*github.PublicKey, error) {
 	return publicKey, nil
 }
 
+// getRepoID returns the numeric repository ID required by the environment endpoints
+func (c *Client) getRepoID(ctx context.Context) (int, error) {
+	c.cache.repoMu.Lock()
+	defer c.cache.repoMu.Unlock()
+
+	if c.cache.repoID != 0 {
+		return c.cache.repoID, nil
+	}
+
+	repository, _, err := c.client.Repositories.Get(ctx, c.owner, c.repo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get repository %s/%s: %w", c.owner, c.repo, err)
+	}
+
+	c.cache.repoID = int(repository.GetID())
+	return c.cache.repoID, nil
+}
+
 func encryptSecret(publicKey, secret string) (string, error) {
 	if publicKey == "" {
 		return "", fmt.Errorf("failed to encrypt: public key is empty")
@@ -99,7 +404,7 @@ type ActionsPublicKey struct {
 }
 
 func (c *Client) GetPublicKeyManual(ctx context.Context) (*ActionsPublicKey, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/secrets/public-key", c.owner, c.repo)
+	url := fmt.Sprintf("%srepos/%s/%s/actions/secrets/public-key", c.client.BaseURL, c.owner, c.repo)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -107,9 +412,9 @@ func (c *Client) GetPublicKeyManual(ctx context.Context) (*ActionsPublicKey, err
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
 
-	resp, err := http.DefaultClient.Do(req)
+	// The API client's transport adds the Authorization header
+	resp, err := c.client.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -2,8 +2,15 @@ package github
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -134,4 +141,194 @@ func TestMockErrorHandling(t *testing.T) {
 	_, err = mockClient.GetSecret(ctx, "test")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
-}
\ No newline at end of file
+}
+
+func TestMockListSecrets(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-token", "test-owner", "test-repo")
+
+	require.NoError(t, mockClient.CreateOrUpdateSecret(ctx, "TOKEN", "value"))
+	require.NoError(t, mockClient.CreateOrUpdateSecret(ctx, "API_KEY", "value"))
+
+	secrets, err := mockClient.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "API_KEY", secrets[0].Name)
+	assert.Equal(t, "TOKEN", secrets[1].Name)
+	assert.False(t, secrets[0].UpdatedAt.IsZero())
+
+	// Test error on ListSecrets
+	mockClient.SetError("ListSecrets", fmt.Errorf("API rate limit exceeded"))
+	_, err = mockClient.ListSecrets(ctx)
+	assert.Error(t, err)
+}
+
+// newTestClient returns a client that sends requests to the given test server
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	client := NewClient("test-token", "owner", "repo")
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.client.BaseURL = baseURL
+	return client
+}
+
+func TestListSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		// Serve two pages to verify that pagination is followed
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"total_count":2,"secrets":[{"name":"TOKEN","created_at":"2024-01-02T00:00:00Z","updated_at":"2024-01-03T00:00:00Z"}]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/actions/secrets?page=2>; rel="next"`, "http://"+r.Host))
+		fmt.Fprint(w, `{"total_count":2,"secrets":[{"name":"API_KEY","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T12:00:00Z"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	secrets, err := client.ListSecrets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "API_KEY", secrets[0].Name)
+	assert.Equal(t, "TOKEN", secrets[1].Name)
+	assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), secrets[1].UpdatedAt.UTC())
+}
+
+func TestDeleteSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/API_KEY", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	require.NoError(t, client.DeleteSecret(context.Background(), "API_KEY"))
+
+	// Unknown secrets are reported as not found
+	err := client.DeleteSecret(context.Background(), "MISSING")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secret MISSING not found")
+}
+
+func TestMockDeleteSecret(t *testing.T) {
+	ctx := context.Background()
+	mockClient := NewMockClient("test-token", "test-owner", "test-repo")
+
+	require.NoError(t, mockClient.CreateOrUpdateSecret(ctx, "API_KEY", "value"))
+	require.NoError(t, mockClient.DeleteSecret(ctx, "API_KEY"))
+
+	_, err := mockClient.GetSecret(ctx, "API_KEY")
+	assert.Error(t, err)
+
+	err = mockClient.DeleteSecret(ctx, "API_KEY")
+	assert.Error(t, err)
+}
+
+func TestCreateOrUpdateEnvSecret(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":42}`)
+	})
+	mux.HandleFunc("/repositories/42/environments/production/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/repositories/42/environments/production/secrets/API_KEY", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server).WithEnvironment("production")
+	err := client.CreateOrUpdateSecret(context.Background(), "API_KEY", "secret-value")
+	require.NoError(t, err)
+
+	assert.Equal(t, "123", stored["key_id"])
+	assert.NotEmpty(t, stored["encrypted_value"])
+	assert.NotEqual(t, "secret-value", stored["encrypted_value"])
+}
+
+func TestCreateOrUpdateSecretFetchesPublicKeyOnce(t *testing.T) {
+	var keyRequests int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&keyRequests, 1)
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, client.CreateOrUpdateSecret(context.Background(), fmt.Sprintf("SECRET_%d", i), "value"))
+		}(i)
+	}
+	wg.Wait()
+
+	// Every write shares the key fetched by the first one, and clones share the cache
+	assert.Equal(t, int32(1), keyRequests)
+	assert.Equal(t, client.cache, client.WithStore(StoreDependabot).cache)
+}
+
+func TestNewClientWithOptionsEnterprise(t *testing.T) {
+	opts := ClientOptions{BaseURL: "https://ghes.example.com/api/v3/"}
+	client, err := NewClientWithOptions("test-token", "owner", "repo", opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://ghes.example.com/api/v3/", client.client.BaseURL.String())
+	assert.Equal(t, "https://ghes.example.com/api/uploads/", client.client.UploadURL.String())
+	assert.Equal(t, "ghes.example.com", opts.Hostname())
+}
+
+func TestNewClientWithOptionsDefault(t *testing.T) {
+	client, err := NewClientWithOptions("test-token", "owner", "repo", ClientOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://api.github.com/", client.client.BaseURL.String())
+	assert.Equal(t, "github.com", ClientOptions{}.Hostname())
+}
+
+func TestGetPublicKeyManualUsesBaseURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	publicKey, err := newTestClient(t, server).GetPublicKeyManual(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "123", publicKey.KeyID)
+}
+
+func TestValidateSecretName(t *testing.T) {
+	for _, name := range []string{"API_KEY", "token2", "_PRIVATE"} {
+		assert.NoError(t, ValidateSecretName(name), name)
+	}
+
+	tests := map[string]string{
+		"":             "secret name cannot be empty",
+		"GITHUB_TOKEN": "secret name GITHUB_TOKEN must not start with GITHUB_",
+		"2FA_SEED":     "secret name 2FA_SEED must not start with a number",
+		"API-KEY":      "secret name API-KEY may only contain letters, numbers and underscores",
+	}
+	for name, wantErr := range tests {
+		assert.EqualError(t, ValidateSecretName(name), wantErr)
+	}
+}
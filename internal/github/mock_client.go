@@ -3,13 +3,17 @@ package github
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 // MockClient is a mock implementation of GitHub client for testing
 type MockClient struct {
 	mu      sync.Mutex
 	secrets map[string]string
+	infos   map[string]SecretInfo
+	vars    map[string]Variable
 	errors  map[string]error
 	owner   string
 	repo    string
@@ -23,6 +27,8 @@ func NewMockClient(token, owner, repo string) *MockClient {
 		repo:    repo,
 		token:   token,
 		secrets: make(map[string]string),
+		infos:   make(map[string]SecretInfo),
+		vars:    make(map[string]Variable),
 		errors:  make(map[string]error),
 	}
 }
@@ -50,9 +56,52 @@ func (m *MockClient) CreateOrUpdateSecret(ctx context.Context, name, value strin
 
 	// Store the encrypted value (in real implementation this would be encrypted)
 	m.secrets[name] = value
+
+	now := time.Now()
+	info, exists := m.infos[name]
+	if !exists {
+		info = SecretInfo{Name: name, CreatedAt: now}
+	}
+	info.UpdatedAt = now
+	m.infos[name] = info
+	return nil
+}
+
+// DeleteSecret mocks the DeleteSecret method
+func (m *MockClient) DeleteSecret(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["DeleteSecret"]; err != nil {
+		return err
+	}
+
+	if _, exists := m.secrets[name]; !exists {
+		return fmt.Errorf("secret %s %w", name, errSecretNotFound)
+	}
+
+	delete(m.secrets, name)
+	delete(m.infos, name)
 	return nil
 }
 
+// ListSecrets mocks the ListSecrets method
+func (m *MockClient) ListSecrets(ctx context.Context) ([]SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["ListSecrets"]; err != nil {
+		return nil, err
+	}
+
+	secrets := make([]SecretInfo, 0, len(m.infos))
+	for _, info := range m.infos {
+		secrets = append(secrets, info)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
 // GetSecret mocks getting a secret (note: GitHub API doesn't support this)
 func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error) {
 	m.mu.Lock()
@@ -70,3 +119,56 @@ func (m *MockClient) GetSecret(ctx context.Context, name string) (string, error)
 	return value, nil
 }
 
+// CreateOrUpdateVariable mocks the CreateOrUpdateVariable method
+func (m *MockClient) CreateOrUpdateVariable(ctx context.Context, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["CreateOrUpdateVariable"]; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	variable, exists := m.vars[name]
+	if !exists {
+		variable = Variable{Name: name, CreatedAt: now}
+	}
+	variable.Value = value
+	variable.UpdatedAt = now
+	m.vars[name] = variable
+	return nil
+}
+
+// DeleteVariable mocks the DeleteVariable method
+func (m *MockClient) DeleteVariable(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["DeleteVariable"]; err != nil {
+		return err
+	}
+
+	if _, exists := m.vars[name]; !exists {
+		return fmt.Errorf("variable %s %w", name, errVariableNotFound)
+	}
+
+	delete(m.vars, name)
+	return nil
+}
+
+// ListVariables mocks the ListVariables method
+func (m *MockClient) ListVariables(ctx context.Context) ([]Variable, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.errors["ListVariables"]; err != nil {
+		return nil, err
+	}
+
+	variables := make([]Variable, 0, len(m.vars))
+	for _, variable := range m.vars {
+		variables = append(variables, variable)
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
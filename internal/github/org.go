@@ -0,0 +1,229 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/oauth2"
+)
+
+// Visibility values of organization secrets
+const (
+	VisibilityAll      = "all"
+	VisibilityPrivate  = "private"
+	VisibilitySelected = "selected"
+)
+
+// OrgSecretSettings controls which repositories of the organization can use a secret
+type OrgSecretSettings struct {
+	Visibility            string
+	SelectedRepositoryIDs []int64
+}
+
+// String encodes the settings as "all", "private" or "selected:<id>,<id>"
+func (s OrgSecretSettings) String() string {
+	if s.Visibility != VisibilitySelected {
+		return s.Visibility
+	}
+
+	ids := make([]string, len(s.SelectedRepositoryIDs))
+	for i, id := range s.SelectedRepositoryIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return VisibilitySelected + ":" + strings.Join(ids, ",")
+}
+
+// Validate checks the visibility value
+func (s OrgSecretSettings) Validate() error {
+	switch s.Visibility {
+	case VisibilityAll, VisibilityPrivate:
+		if len(s.SelectedRepositoryIDs) > 0 {
+			return fmt.Errorf("selected repositories can only be set with visibility %s", VisibilitySelected)
+		}
+		return nil
+	case VisibilitySelected:
+		return nil
+	default:
+		return fmt.Errorf("invalid visibility: %s (must be all, private or selected)", s.Visibility)
+	}
+}
+
+// ParseOrgSecretSettings parses settings encoded by OrgSecretSettings.String
+func ParseOrgSecretSettings(value string) (OrgSecretSettings, error) {
+	visibility, ids, _ := strings.Cut(value, ":")
+	settings := OrgSecretSettings{Visibility: visibility}
+
+	if ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			repoID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return OrgSecretSettings{}, fmt.Errorf("invalid repository ID %q: %w", id, err)
+			}
+			settings.SelectedRepositoryIDs = append(settings.SelectedRepositoryIDs, repoID)
+		}
+	}
+
+	return settings, settings.Validate()
+}
+
+// NewOrgClient creates a client that manages the secrets of an organization
+func NewOrgClient(token, org string) *Client {
+	ctx := context.Background()
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	client := github.NewClient(withRetries(tc))
+
+	return &Client{
+		client: client,
+		owner:  org,
+		org:    org,
+		token:  token,
+		cache:  newClientCache(),
+	}
+}
+
+// NewOrgClientWithOptions creates an organization client, for GitHub Enterprise Server when a base URL is given
+func NewOrgClientWithOptions(token, org string, opts ClientOptions) (*Client, error) {
+	client, err := newAPIClient(token, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		client: client,
+		owner:  org,
+		org:    org,
+		token:  token,
+		cache:  newClientCache(),
+	}, nil
+}
+
+// IsOrg reports whether the client manages organization secrets
+func (c *Client) IsOrg() bool {
+	return c.org != ""
+}
+
+// CreateOrUpdateOrgSecret creates or updates an organization secret with the given visibility
+func (c *Client) CreateOrUpdateOrgSecret(ctx context.Context, name, value string, settings OrgSecretSettings) error {
+	if c.org == "" {
+		return fmt.Errorf("organization secrets require an organization client")
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	publicKey, err := c.getPublicKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	encryptedValue, err := encryptSecret(publicKey.GetKey(), value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:           name,
+		KeyID:          publicKey.GetKeyID(),
+		EncryptedValue: encryptedValue,
+		Visibility:     settings.Visibility,
+	}
+	if settings.Visibility == VisibilitySelected {
+		secret.SelectedRepositoryIDs = github.SelectedRepoIDs(settings.SelectedRepositoryIDs)
+	}
+
+	if c.usesStoreAPI() {
+		_, err = c.putStoreSecret(ctx, secret)
+	} else {
+		_, err = c.client.Actions.CreateOrUpdateOrgSecret(ctx, c.org, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create/update secret: %w", err)
+	}
+
+	return nil
+}
+
+// GetOrgSecretSettings returns the visibility and selected repositories of an organization secret
+func (c *Client) GetOrgSecretSettings(ctx context.Context, name string) (OrgSecretSettings, error) {
+	if c.org == "" {
+		return OrgSecretSettings{}, fmt.Errorf("organization secrets require an organization client")
+	}
+
+	var secret *github.Secret
+	var resp *github.Response
+	var err error
+	if c.usesStoreAPI() {
+		secret, resp, err = c.getStoreSecret(ctx, name)
+	} else {
+		secret, resp, err = c.client.Actions.GetOrgSecret(ctx, c.org, name)
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return OrgSecretSettings{}, fmt.Errorf("secret %s %w", name, errSecretNotFound)
+		}
+		return OrgSecretSettings{}, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	settings := OrgSecretSettings{Visibility: secret.Visibility}
+	if settings.Visibility != VisibilitySelected {
+		return settings, nil
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var page *github.SelectedReposList
+		if c.usesStoreAPI() {
+			page, resp, err = c.listStoreSelectedRepos(ctx, name, opts)
+		} else {
+			page, resp, err = c.client.Actions.ListSelectedReposForOrgSecret(ctx, c.org, name, opts)
+		}
+		if err != nil {
+			return OrgSecretSettings{}, fmt.Errorf("failed to list selected repositories: %w", err)
+		}
+
+		for _, repository := range page.Repositories {
+			settings.SelectedRepositoryIDs = append(settings.SelectedRepositoryIDs, repository.GetID())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(settings.SelectedRepositoryIDs, func(i, j int) bool {
+		return settings.SelectedRepositoryIDs[i] < settings.SelectedRepositoryIDs[j]
+	})
+
+	return settings, nil
+}
+
+// ResolveRepositoryIDs converts repository names of the organization (or numeric IDs) into IDs
+func (c *Client) ResolveRepositoryIDs(ctx context.Context, repos []string) ([]int64, error) {
+	ids := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		repo = strings.TrimSpace(repo)
+		if repo == "" {
+			continue
+		}
+
+		if id, err := strconv.ParseInt(repo, 10, 64); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+
+		repository, _, err := c.client.Repositories.Get(ctx, c.owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository %s/%s: %w", c.owner, repo, err)
+		}
+		ids = append(ids, repository.GetID())
+	}
+	return ids, nil
+}
@@ -0,0 +1,140 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestOrgClient returns an organization client that sends requests to the given test server
+func newTestOrgClient(t *testing.T, server *httptest.Server) *Client {
+	client := NewOrgClient("test-token", "my-org")
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.client.BaseURL = baseURL
+	return client
+}
+
+func TestOrgSecretSettingsString(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings OrgSecretSettings
+		expected string
+	}{
+		{name: "all", settings: OrgSecretSettings{Visibility: VisibilityAll}, expected: "all"},
+		{name: "private", settings: OrgSecretSettings{Visibility: VisibilityPrivate}, expected: "private"},
+		{
+			name:     "selected",
+			settings: OrgSecretSettings{Visibility: VisibilitySelected, SelectedRepositoryIDs: []int64{1, 23}},
+			expected: "selected:1,23",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.settings.String())
+
+			parsed, err := ParseOrgSecretSettings(tt.expected)
+			require.NoError(t, err)
+			assert.Equal(t, tt.settings, parsed)
+		})
+	}
+}
+
+func TestParseOrgSecretSettingsInvalid(t *testing.T) {
+	_, err := ParseOrgSecretSettings("public")
+	assert.Error(t, err)
+
+	_, err = ParseOrgSecretSettings("selected:abc")
+	assert.Error(t, err)
+
+	_, err = ParseOrgSecretSettings("all:1")
+	assert.Error(t, err)
+}
+
+func TestCreateOrUpdateOrgSecret(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/orgs/my-org/actions/secrets/REGISTRY_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestOrgClient(t, server)
+	err := client.CreateOrUpdateOrgSecret(context.Background(), "REGISTRY_TOKEN", "value", OrgSecretSettings{
+		Visibility:            VisibilitySelected,
+		SelectedRepositoryIDs: []int64{10, 20},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "selected", stored["visibility"])
+	assert.Equal(t, []interface{}{float64(10), float64(20)}, stored["selected_repository_ids"])
+	assert.NotEmpty(t, stored["encrypted_value"])
+}
+
+func TestCreateOrUpdateSecretKeepsOrgVisibility(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/orgs/my-org/actions/secrets/REGISTRY_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"name":"REGISTRY_TOKEN","visibility":"selected"}`)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/orgs/my-org/actions/secrets/REGISTRY_TOKEN/repositories", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count":1,"repositories":[{"id":42}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestOrgClient(t, server)
+	err := client.CreateOrUpdateSecret(context.Background(), "REGISTRY_TOKEN", "value")
+	require.NoError(t, err)
+
+	assert.Equal(t, "selected", stored["visibility"])
+	assert.Equal(t, []interface{}{float64(42)}, stored["selected_repository_ids"])
+}
+
+func TestCreateOrUpdateSecretNewOrgSecretIsPrivate(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"123","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/orgs/my-org/actions/secrets/NEW_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestOrgClient(t, server)
+	err := client.CreateOrUpdateSecret(context.Background(), "NEW_TOKEN", "value")
+	require.NoError(t, err)
+	assert.Equal(t, "private", stored["visibility"])
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRequestRetries is how often a request is retried after a rate limit or server error
+const maxRequestRetries = 5
+
+var (
+	// serverErrorBackoff is the base delay before retrying a request that failed with a
+	// 5xx response. It doubles with every attempt.
+	serverErrorBackoff = time.Second
+	// secondaryRateLimitWait is the delay after a secondary rate limit response that doesn't
+	// say how long to wait. GitHub asks clients to wait at least a minute.
+	secondaryRateLimitWait = time.Minute
+	// maxRateLimitWait is the longest a request waits for a rate limit to reset. Longer
+	// waits fail the request instead of stalling the run.
+	maxRateLimitWait = 15 * time.Minute
+)
+
+// retryTransport retries requests that GitHub rejected because of its primary or
+// secondary rate limits, waiting as long as the response asks, and requests that
+// failed with a server error, backing off exponentially
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+// withRetries returns the HTTP client with its transport wrapped in a retryTransport
+func withRetries(httpClient *http.Client) *http.Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	retrying := *httpClient
+	retrying.Transport = &retryTransport{base: base}
+	return &retrying
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		// A request body that can't be replayed is sent only once
+		if attempt >= maxRequestRetries || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+		wait, retry := retryDelay(resp, req.Method, attempt, time.Now())
+		if !retry {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying the request of a response, and
+// false when the response is final. Rate limited requests weren't processed and are
+// retried whatever their method; server errors are not retried for POST requests,
+// which may have been applied.
+func retryDelay(resp *http.Response, method string, attempt int, now time.Time) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		wait, limited := rateLimitDelay(resp, attempt, now)
+		if !limited || wait > maxRateLimitWait {
+			return 0, false
+		}
+		return wait, true
+
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented && method != http.MethodPost:
+		if wait, ok := retryAfter(resp); ok {
+			return wait, wait <= maxRateLimitWait
+		}
+		// Back off with jitter so concurrent requests don't hit the server together again
+		backoff := serverErrorBackoff << attempt
+		return backoff + time.Duration(rand.Int63n(int64(backoff)/2+1)), true
+	}
+	return 0, false
+}
+
+// rateLimitDelay returns how long a rate limited response asks to wait, and false
+// when the response is an ordinary permission error
+func rateLimitDelay(resp *http.Response, attempt int, now time.Time) (time.Duration, bool) {
+	if wait, ok := retryAfter(resp); ok {
+		return wait, true
+	}
+
+	// Primary rate limit: wait until the limit resets
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Unix(reset, 0).Sub(now) + time.Second
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, true
+		}
+	}
+
+	// Secondary rate limit without a Retry-After header
+	if resp.StatusCode == http.StatusTooManyRequests || strings.Contains(strings.ToLower(peekBody(resp)), "secondary rate limit") {
+		return secondaryRateLimitWait << attempt, true
+	}
+	return 0, false
+}
+
+// retryAfter parses the Retry-After header of a response, given in seconds
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// peekBody reads the body of a response and puts it back for the caller
+func peekBody(resp *http.Response) string {
+	if resp.Body == nil {
+		return ""
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body)
+}
@@ -0,0 +1,176 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	response := func(status int, headers map[string]string, body string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody}
+		for name, value := range headers {
+			resp.Header.Set(name, value)
+		}
+		if body != "" {
+			resp.Body = io.NopCloser(strings.NewReader(body))
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		method    string
+		attempt   int
+		wantWait  time.Duration
+		wantRetry bool
+	}{
+		{
+			name:      "retry after",
+			resp:      response(http.StatusForbidden, map[string]string{"Retry-After": "30"}, ""),
+			method:    http.MethodPut,
+			wantWait:  30 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "primary rate limit reset",
+			resp:      response(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Unix()+60, 10)}, ""),
+			method:    http.MethodGet,
+			wantWait:  61 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "secondary rate limit",
+			resp:      response(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+			method:    http.MethodPost,
+			attempt:   1,
+			wantWait:  2 * secondaryRateLimitWait,
+			wantRetry: true,
+		},
+		{
+			name:      "too many requests",
+			resp:      response(http.StatusTooManyRequests, nil, ""),
+			method:    http.MethodPut,
+			wantWait:  secondaryRateLimitWait,
+			wantRetry: true,
+		},
+		{
+			name:   "permission error",
+			resp:   response(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "4999"}, `{"message":"Resource not accessible by integration"}`),
+			method: http.MethodPut,
+		},
+		{
+			name:   "reset too far away",
+			resp:   response(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}, ""),
+			method: http.MethodGet,
+		},
+		{
+			name:   "server error of a create",
+			resp:   response(http.StatusBadGateway, nil, ""),
+			method: http.MethodPost,
+		},
+		{
+			name:   "not found",
+			resp:   response(http.StatusNotFound, nil, ""),
+			method: http.MethodGet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, retry := retryDelay(tt.resp, tt.method, tt.attempt, now)
+			assert.Equal(t, tt.wantRetry, retry)
+			assert.Equal(t, tt.wantWait, wait)
+		})
+	}
+
+	// Server errors back off exponentially with up to 50% jitter
+	wait, retry := retryDelay(response(http.StatusServiceUnavailable, nil, ""), http.MethodPut, 2, now)
+	assert.True(t, retry)
+	assert.GreaterOrEqual(t, wait, 4*serverErrorBackoff)
+	assert.LessOrEqual(t, wait, 6*serverErrorBackoff)
+}
+
+func TestRetryTransport(t *testing.T) {
+	serverErrorBackoff = time.Millisecond
+	defer func() { serverErrorBackoff = time.Second }()
+
+	var requests int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := withRetries(&http.Client{})
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"name":"API_KEY"}`))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, int32(3), requests)
+	// The body is sent again with every attempt
+	assert.Equal(t, []string{`{"name":"API_KEY"}`, `{"name":"API_KEY"}`, `{"name":"API_KEY"}`}, bodies)
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	serverErrorBackoff = time.Millisecond
+	defer func() { serverErrorBackoff = time.Second }()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	resp, err := withRetries(&http.Client{}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(maxRequestRetries+1), requests)
+}
+
+func TestRetryTransportHonorsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = withRetries(&http.Client{}).Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// Store identifies one of the separate secret stores GitHub keeps for a repository
+// or organization. Each store has its own public key and secret endpoints.
+type Store string
+
+const (
+	StoreActions    Store = "actions"
+	StoreDependabot Store = "dependabot"
+	StoreCodespaces Store = "codespaces"
+)
+
+// ParseStore parses a store name as given on the command line. An empty name selects the Actions store.
+func ParseStore(value string) (Store, error) {
+	switch store := Store(strings.ToLower(value)); store {
+	case "":
+		return StoreActions, nil
+	case StoreActions, StoreDependabot, StoreCodespaces:
+		return store, nil
+	}
+	return "", fmt.Errorf("invalid secret store: %s (must be actions, dependabot or codespaces)", value)
+}
+
+// WithStore returns a client that manages the secrets of the given store
+// instead of the Actions store
+func (c *Client) WithStore(store Store) *Client {
+	clone := *c
+	clone.store = store
+	return &clone
+}
+
+// Store returns the secret store the client manages
+func (c *Client) Store() Store {
+	if c.store == "" {
+		return StoreActions
+	}
+	return c.store
+}
+
+// usesStoreAPI reports whether the client talks to the Dependabot or Codespaces
+// endpoints, which go-github doesn't cover uniformly and are called directly
+func (c *Client) usesStoreAPI() bool {
+	return c.Store() != StoreActions
+}
+
+// storeSecretsPath returns the API path of the secrets of the store
+func (c *Client) storeSecretsPath() (string, error) {
+	if c.environment != "" {
+		return "", fmt.Errorf("environment secrets are only available in the %s store", StoreActions)
+	}
+	if c.org != "" {
+		return fmt.Sprintf("orgs/%s/%s/secrets", c.org, c.store), nil
+	}
+	return fmt.Sprintf("repos/%s/%s/%s/secrets", c.owner, c.repo, c.store), nil
+}
+
+// doRequest sends a request for endpoints that go-github doesn't cover and decodes the response into v
+func (c *Client) doRequest(ctx context.Context, method, path string, body, v interface{}) (*github.Response, error) {
+	req, err := c.client.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(ctx, req, v)
+}
+
+func (c *Client) getStorePublicKey(ctx context.Context) (*github.PublicKey, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := new(github.PublicKey)
+	if _, err := c.doRequest(ctx, http.MethodGet, path+"/public-key", nil, publicKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+func (c *Client) putStoreSecret(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, http.MethodPut, path+"/"+secret.Name, secret, nil)
+}
+
+func (c *Client) deleteStoreSecret(ctx context.Context, name string) (*github.Response, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, http.MethodDelete, path+"/"+name, nil, nil)
+}
+
+func (c *Client) getStoreSecret(ctx context.Context, name string) (*github.Secret, *github.Response, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret := new(github.Secret)
+	resp, err := c.doRequest(ctx, http.MethodGet, path+"/"+name, nil, secret)
+	if err != nil {
+		return nil, resp, err
+	}
+	return secret, resp, nil
+}
+
+func (c *Client) listStoreSecrets(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secrets := new(github.Secrets)
+	resp, err := c.doRequest(ctx, http.MethodGet, path+listQuery(opts), nil, secrets)
+	if err != nil {
+		return nil, resp, err
+	}
+	return secrets, resp, nil
+}
+
+func (c *Client) listStoreSelectedRepos(ctx context.Context, name string, opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error) {
+	path, err := c.storeSecretsPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repos := new(github.SelectedReposList)
+	resp, err := c.doRequest(ctx, http.MethodGet, path+"/"+name+"/repositories"+listQuery(opts), nil, repos)
+	if err != nil {
+		return nil, resp, err
+	}
+	return repos, resp, nil
+}
+
+// listQuery encodes the pagination options as a query string
+func listQuery(opts *github.ListOptions) string {
+	query := url.Values{}
+	if opts.PerPage != 0 {
+		query.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if opts.Page != 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStore(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Store
+		wantErr  bool
+	}{
+		{input: "", expected: StoreActions},
+		{input: "actions", expected: StoreActions},
+		{input: "Dependabot", expected: StoreDependabot},
+		{input: "codespaces", expected: StoreCodespaces},
+		{input: "packages", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			store, err := ParseStore(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, store)
+		})
+	}
+}
+
+func TestCreateOrUpdateDependabotSecret(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/dependabot/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"456","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/repos/owner/repo/dependabot/secrets/REGISTRY_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server).WithStore(StoreDependabot)
+	err := client.CreateOrUpdateSecret(context.Background(), "REGISTRY_TOKEN", "secret-value")
+	require.NoError(t, err)
+
+	assert.Equal(t, "456", stored["key_id"])
+	assert.NotEmpty(t, stored["encrypted_value"])
+}
+
+func TestCreateOrUpdateCodespacesOrgSecret(t *testing.T) {
+	var stored map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/codespaces/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"key_id":"789","key":"RRjlhKlgU2SicuhpgO3vV8BDVmFpNMIYY0k8mp9FqrU="}`)
+	})
+	mux.HandleFunc("/orgs/my-org/codespaces/secrets/NPM_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestOrgClient(t, server).WithStore(StoreCodespaces)
+	settings := OrgSecretSettings{Visibility: VisibilitySelected, SelectedRepositoryIDs: []int64{1, 2}}
+	err := client.CreateOrUpdateOrgSecret(context.Background(), "NPM_TOKEN", "secret-value", settings)
+	require.NoError(t, err)
+
+	assert.Equal(t, "789", stored["key_id"])
+	assert.Equal(t, "selected", stored["visibility"])
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, stored["selected_repository_ids"])
+}
+
+func TestListAndDeleteStoreSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/codespaces/secrets", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		fmt.Fprint(w, `{"total_count":1,"secrets":[{"name":"DOTFILES_TOKEN","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-02-01T00:00:00Z"}]}`)
+	})
+	mux.HandleFunc("/repos/owner/repo/codespaces/secrets/MISSING", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server).WithStore(StoreCodespaces)
+
+	secrets, err := client.ListSecrets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "DOTFILES_TOKEN", secrets[0].Name)
+
+	err = client.DeleteSecret(context.Background(), "MISSING")
+	assert.True(t, IsSecretNotFound(err))
+}
+
+func TestStoreRejectsEnvironment(t *testing.T) {
+	client := NewClient("test-token", "owner", "repo").WithEnvironment("production").WithStore(StoreDependabot)
+
+	err := client.CreateOrUpdateSecret(context.Background(), "API_KEY", "value")
+	assert.ErrorContains(t, err, "only available in the actions store")
+}
@@ -0,0 +1,117 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// errVariableNotFound is returned when GitHub reports that a variable does not exist
+var errVariableNotFound = errors.New("not found")
+
+// IsVariableNotFound reports whether err means the variable does not exist in GitHub
+func IsVariableNotFound(err error) bool {
+	return errors.Is(err, errVariableNotFound)
+}
+
+// Variable is an Actions configuration variable. Unlike secrets, values are readable.
+type Variable struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type variablesPage struct {
+	TotalCount int         `json:"total_count"`
+	Variables  []*Variable `json:"variables"`
+}
+
+type variableRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// variablesPath returns the API path of the repository or environment variables
+func (c *Client) variablesPath() (string, error) {
+	if c.org != "" {
+		return "", fmt.Errorf("organization variables are not supported")
+	}
+	if c.environment != "" {
+		return fmt.Sprintf("repos/%s/%s/environments/%s/variables", c.owner, c.repo, c.environment), nil
+	}
+	return fmt.Sprintf("repos/%s/%s/actions/variables", c.owner, c.repo), nil
+}
+
+// CreateOrUpdateVariable updates a repository or environment variable, creating it when it doesn't exist
+func (c *Client) CreateOrUpdateVariable(ctx context.Context, name, value string) error {
+	path, err := c.variablesPath()
+	if err != nil {
+		return err
+	}
+
+	body := &variableRequest{Name: name, Value: value}
+	resp, err := c.doRequest(ctx, http.MethodPatch, path+"/"+name, body, nil)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to update variable: %w", err)
+	}
+
+	if _, err := c.doRequest(ctx, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("failed to create variable: %w", err)
+	}
+	return nil
+}
+
+// DeleteVariable deletes a repository or environment variable
+func (c *Client) DeleteVariable(ctx context.Context, name string) error {
+	path, err := c.variablesPath()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, path+"/"+name, nil, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("variable %s %w", name, errVariableNotFound)
+		}
+		return fmt.Errorf("failed to delete variable: %w", err)
+	}
+
+	return nil
+}
+
+// ListVariables returns all repository or environment variables, following pagination
+func (c *Client) ListVariables(ctx context.Context) ([]Variable, error) {
+	path, err := c.variablesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var variables []Variable
+	opts := &github.ListOptions{PerPage: 30}
+	for {
+		page := new(variablesPage)
+		resp, err := c.doRequest(ctx, http.MethodGet, path+listQuery(opts), nil, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list variables: %w", err)
+		}
+
+		for _, variable := range page.Variables {
+			variables = append(variables, *variable)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return variables, nil
+}
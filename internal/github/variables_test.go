@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListVariables(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"total_count":2,"variables":[{"name":"REGION","value":"eu-west-1","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/actions/variables?page=2>; rel="next"`, "http://"+r.Host))
+		fmt.Fprint(w, `{"total_count":2,"variables":[{"name":"LOG_LEVEL","value":"debug","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-02-01T00:00:00Z"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	variables, err := newTestClient(t, server).ListVariables(context.Background())
+	require.NoError(t, err)
+	require.Len(t, variables, 2)
+	assert.Equal(t, "LOG_LEVEL", variables[0].Name)
+	assert.Equal(t, "debug", variables[0].Value)
+	assert.Equal(t, "REGION", variables[1].Name)
+}
+
+func TestCreateOrUpdateVariableCreatesMissing(t *testing.T) {
+	var created map[string]string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/environments/production/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/owner/repo/environments/production/variables", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server).WithEnvironment("production")
+	err := client.CreateOrUpdateVariable(context.Background(), "REGION", "eu-west-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "REGION", "value": "eu-west-1"}, created)
+}
+
+func TestCreateOrUpdateVariableUpdatesExisting(t *testing.T) {
+	var updated map[string]string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	err := newTestClient(t, server).CreateOrUpdateVariable(context.Background(), "REGION", "us-east-1")
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", updated["value"])
+}
+
+func TestDeleteVariable(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	require.NoError(t, client.DeleteVariable(context.Background(), "REGION"))
+
+	err := client.DeleteVariable(context.Background(), "MISSING")
+	assert.True(t, IsVariableNotFound(err))
+	assert.EqualError(t, err, "variable MISSING not found")
+}
+
+func TestOrgVariablesNotSupported(t *testing.T) {
+	_, err := NewOrgClient("test-token", "my-org").ListVariables(context.Background())
+	assert.EqualError(t, err, "organization variables are not supported")
+}
+
+func TestMockVariables(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockClient("token", "owner", "repo")
+
+	require.NoError(t, mock.CreateOrUpdateVariable(ctx, "REGION", "eu-west-1"))
+	require.NoError(t, mock.CreateOrUpdateVariable(ctx, "REGION", "us-east-1"))
+
+	variables, err := mock.ListVariables(ctx)
+	require.NoError(t, err)
+	require.Len(t, variables, 1)
+	assert.Equal(t, "us-east-1", variables[0].Value)
+
+	require.NoError(t, mock.DeleteVariable(ctx, "REGION"))
+	assert.Error(t, mock.DeleteVariable(ctx, "REGION"))
+}